@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"kahoot_bsu/internal/app/telegram"
+	"log"
+	"os"
+)
+
+// fsmgraph exports the conversation graph of the bot
+func main() {
+	var (
+		format = flag.String("format", "dot", "Output format (dot, drawio)")
+		output = flag.String("o", "", "Output file, stdout when empty")
+	)
+	flag.Parse()
+
+	router := telegram.NewGraphRouter()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	var err error
+	switch *format {
+	case "dot":
+		err = router.Graph().WriteDOT(w)
+	case "drawio":
+		err = router.Graph().WriteDrawIO(w)
+	default:
+		log.Fatalf("Unknown format %q, use dot or drawio", *format)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write the graph: %v", err)
+	}
+}
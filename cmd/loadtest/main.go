@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/session"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+func main() {
+	// Command line flags
+	var (
+		apiURL        = flag.String("api", "http://localhost:8080", "Kahoot BSU server URL")
+		quizID        = flag.String("quiz", "", "ID of the quiz to play")
+		initData      = flag.String("init-data", os.Getenv("LOADTEST_INIT_DATA"), "Telegram Mini App init data of the host, see the Authorization header of the web app")
+		players       = flag.Int("players", 300, "Number of simulated players")
+		joinRate      = flag.Float64("join-rate", 50, "Players joining per second")
+		thinkDist     = flag.String("think", "normal", "Think time distribution (fixed, uniform, normal, exponential)")
+		thinkMean     = flag.Duration("think-mean", 3*time.Second, "Mean think time before answering")
+		thinkSpread   = flag.Duration("think-spread", time.Second, "Think time spread: half-width for uniform, standard deviation for normal")
+		correctMean   = flag.Float64("correct-mean", 0.7, "Mean share of correct answers of a player")
+		correctSpread = flag.Float64("correct-spread", 0.2, "Player accuracy is uniform within correct-mean +/- correct-spread")
+		questionWait  = flag.Duration("question-wait", 30*time.Second, "Longest wait for answers before opening the next question")
+		shuffle       = flag.Bool("shuffle", true, "Shuffle questions and options per player")
+		seed          = flag.Uint64("seed", 0, "Random seed, 0 picks a random one")
+	)
+	flag.Parse()
+
+	if *quizID == "" {
+		log.Fatal("Quiz ID is required. Provide it with -quiz flag")
+	}
+	if *players <= 0 || *joinRate <= 0 {
+		log.Fatal("Players and join rate must be positive")
+	}
+
+	think, err := newThinkTime(*thinkDist, *thinkMean, *thinkSpread)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+	log.Printf("Load test of quiz %s with %d players, seed %d", *quizID, *players, *seed)
+
+	ctx := context.Background()
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	// The host is authenticated, players join anonymously
+	api := &apiClient{
+		baseURL:  strings.TrimSuffix(*apiURL, "/"),
+		client:   client,
+		initData: *initData,
+	}
+	playerAPI := &apiClient{
+		baseURL: api.baseURL,
+		client:  client,
+	}
+
+	// The quiz questions are the answer key of the simulated players
+	var questions []question.Question
+	if err := api.do(ctx, http.MethodGet, "/api/quizzes/"+*quizID+"/questions", nil, &questions); err != nil {
+		log.Fatalf("Failed to fetch quiz questions: %v", err)
+	}
+	key := make(map[string]bool)
+	for _, q := range questions {
+		for _, option := range q.Options {
+			key[option.ID] = option.IsCorrect
+		}
+	}
+
+	var gameSession session.Session
+	settings := map[string]any{
+		"settings": session.Settings{ShuffleQuestions: *shuffle, ShuffleOptions: *shuffle},
+	}
+	if err := api.do(ctx, http.MethodPost, "/api/quizzes/"+*quizID+"/sessions", settings, &gameSession); err != nil {
+		log.Fatalf("Failed to create session: %v", err)
+	}
+	log.Printf("Created session %s with join code %s", gameSession.ID, gameSession.JoinCode)
+
+	run := newRun()
+
+	// Join at the given rate like students entering a lecture hall,
+	// every player is connected before the game starts
+	var (
+		joined  sync.WaitGroup
+		playing sync.WaitGroup
+		ticker  = time.NewTicker(time.Duration(float64(time.Second) / *joinRate))
+	)
+	for i := range *players {
+		<-ticker.C
+
+		rnd := rand.New(rand.NewPCG(*seed, uint64(i)))
+		accuracy := min(1, max(0, *correctMean+(rnd.Float64()*2-1)**correctSpread))
+
+		p := &player{
+			login:    fmt.Sprintf("loadtest-%03d", i+1),
+			accuracy: accuracy,
+			think:    think,
+			key:      key,
+			rnd:      rnd,
+			run:      run,
+		}
+
+		joined.Add(1)
+		go func() {
+			defer joined.Done()
+
+			if err := p.join(ctx, playerAPI, gameSession.JoinCode); err != nil {
+				log.Printf("Player %s failed to join: %v", p.login, err)
+				run.failedJoin()
+				return
+			}
+
+			playing.Add(1)
+			go func() {
+				defer playing.Done()
+				p.play()
+			}()
+		}()
+	}
+	ticker.Stop()
+	joined.Wait()
+
+	connected := *players - run.joinFailures()
+	log.Printf("%d players connected", connected)
+
+	// Host: open the questions one by one until the session is finished
+	for index := 0; ; index++ {
+		action := "next"
+		if index == 0 {
+			action = "start"
+		}
+
+		run.open(index)
+
+		var state session.Session
+		if err := api.do(ctx, http.MethodPost, "/api/sessions/"+gameSession.ID+"/"+action, nil, &state); err != nil {
+			log.Fatalf("Failed to %s question %d: %v", action, index+1, err)
+		}
+
+		if state.Status&session.StatusFinished != 0 {
+			break
+		}
+
+		answered := run.waitAnswers(index, connected, *questionWait)
+		log.Printf("Question %d: %d/%d players answered", index+1, answered, connected)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		playing.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(*questionWait):
+		log.Printf("Some players did not receive the end of the game")
+	}
+
+	run.report(*players)
+}
+
+// apiClient calls the JSON API of the server
+type apiClient struct {
+	baseURL  string
+	client   *http.Client
+	initData string
+}
+
+func (a *apiClient) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.initData != "" {
+		req.Header.Set("Authorization", "tma "+a.initData)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, apiErr.Error)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/interfaces/http/handlers/kahoot"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// player is a simulated participant connected over the real-time protocol
+type player struct {
+	login    string
+	accuracy float64
+	think    thinkTime
+	key      map[string]bool
+	rnd      *rand.Rand
+	run      *run
+
+	participant session.Participant
+	conn        *websocket.Conn
+
+	mu      sync.Mutex
+	seq     int
+	pending map[int]pendingAnswer
+}
+
+// pendingAnswer is an answer waiting for its acknowledgement
+type pendingAnswer struct {
+	questionIndex int
+	sentAt        time.Time
+}
+
+// join registers the player in the session and opens their connection
+func (p *player) join(ctx context.Context, api *apiClient, joinCode string) error {
+	request := map[string]string{"join_code": joinCode, "login": p.login}
+	if err := api.do(ctx, http.MethodPost, "/api/sessions/join", request, &p.participant); err != nil {
+		return err
+	}
+
+	socketURL, err := url.Parse(api.baseURL + "/api/participants/" + p.participant.ID + "/ws")
+	if err != nil {
+		return err
+	}
+
+	origin := socketURL.String()
+	if socketURL.Scheme == "https" {
+		socketURL.Scheme = "wss"
+	} else {
+		socketURL.Scheme = "ws"
+	}
+
+	p.conn, err = websocket.Dial(socketURL.String(), "", origin)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	p.pending = make(map[int]pendingAnswer)
+	return nil
+}
+
+// play answers every question until the game is over or the connection is lost
+func (p *player) play() {
+	defer p.conn.Close()
+
+	for {
+		var message kahoot.SocketMessage
+		if err := websocket.JSON.Receive(p.conn, &message); err != nil {
+			p.run.disconnected()
+			return
+		}
+
+		switch message.Type {
+		case kahoot.MessageQuestion:
+			p.run.broadcast.add(time.Since(p.run.openedAt(message.QuestionIndex)))
+
+			// The random source is not safe for concurrent use, decide here
+			option := p.choose(message.Question)
+			delay := p.think.sample(p.rnd)
+			go p.answer(message.QuestionIndex, message.Question.ID, option, delay)
+		case kahoot.MessageAck:
+			answer, ok := p.acknowledged(message.Seq)
+			if !ok {
+				continue
+			}
+			p.run.ack.add(time.Since(answer.sentAt))
+			p.run.answered(answer.questionIndex, message.Answer.IsCorrect)
+		case kahoot.MessageError:
+			answer, ok := p.acknowledged(message.Seq)
+			if !ok {
+				continue
+			}
+			p.run.rejected(answer.questionIndex, message.Error)
+		case kahoot.MessageFinished:
+			return
+		}
+	}
+}
+
+// choose picks a correct option with the probability of the player's accuracy
+func (p *player) choose(q *question.Question) string {
+	correct := p.rnd.Float64() < p.accuracy
+
+	var candidates []string
+	for _, option := range q.Options {
+		if p.key[option.ID] == correct {
+			candidates = append(candidates, option.ID)
+		}
+	}
+
+	// Questions with only correct or only wrong options
+	if len(candidates) == 0 {
+		for _, option := range q.Options {
+			candidates = append(candidates, option.ID)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	return candidates[p.rnd.IntN(len(candidates))]
+}
+
+// answer sends the chosen option after thinking for delay
+func (p *player) answer(questionIndex int, questionID, optionID string, delay time.Duration) {
+	time.Sleep(delay)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seq++
+	p.pending[p.seq] = pendingAnswer{questionIndex: questionIndex, sentAt: time.Now()}
+
+	message := kahoot.SocketMessage{
+		Type:       kahoot.MessageAnswer,
+		Seq:        p.seq,
+		QuestionID: questionID,
+		OptionID:   optionID,
+	}
+	if err := websocket.JSON.Send(p.conn, message); err != nil {
+		delete(p.pending, p.seq)
+	}
+}
+
+// acknowledged removes the answer with the sequence number from the pending ones
+func (p *player) acknowledged(seq int) (pendingAnswer, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	answer, ok := p.pending[seq]
+	delete(p.pending, seq)
+	return answer, ok
+}
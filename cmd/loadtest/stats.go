@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// thinkTime samples how long a player takes to answer
+type thinkTime struct {
+	distribution string
+	mean         time.Duration
+	spread       time.Duration
+}
+
+func newThinkTime(distribution string, mean, spread time.Duration) (thinkTime, error) {
+	switch distribution {
+	case "fixed", "uniform", "normal", "exponential":
+		return thinkTime{distribution: distribution, mean: mean, spread: spread}, nil
+	default:
+		return thinkTime{}, fmt.Errorf("unknown think time distribution %q", distribution)
+	}
+}
+
+func (t thinkTime) sample(rnd *rand.Rand) time.Duration {
+	var sample float64
+	switch t.distribution {
+	case "uniform":
+		sample = float64(t.mean) + (rnd.Float64()*2-1)*float64(t.spread)
+	case "normal":
+		sample = float64(t.mean) + rnd.NormFloat64()*float64(t.spread)
+	case "exponential":
+		sample = rnd.ExpFloat64() * float64(t.mean)
+	default:
+		sample = float64(t.mean)
+	}
+	return time.Duration(max(0, sample))
+}
+
+// latencies collects durations from many goroutines
+type latencies struct {
+	mu     sync.Mutex
+	values []time.Duration
+}
+
+func (l *latencies) add(d time.Duration) {
+	l.mu.Lock()
+	l.values = append(l.values, d)
+	l.mu.Unlock()
+}
+
+// summary formats the percentiles of the collected durations
+func (l *latencies) summary() string {
+	l.mu.Lock()
+	values := slices.Clone(l.values)
+	l.mu.Unlock()
+
+	if len(values) == 0 {
+		return "no samples"
+	}
+	slices.Sort(values)
+
+	const precision = 100 * time.Microsecond
+
+	// Nearest-rank percentile
+	percentile := func(p float64) time.Duration {
+		rank := int(p*float64(len(values))+0.999999) - 1
+		return values[min(max(rank, 0), len(values)-1)]
+	}
+
+	return fmt.Sprintf("n=%d p50=%v p90=%v p95=%v p99=%v max=%v",
+		len(values),
+		percentile(0.50).Round(precision),
+		percentile(0.90).Round(precision),
+		percentile(0.95).Round(precision),
+		percentile(0.99).Round(precision),
+		values[len(values)-1].Round(precision),
+	)
+}
+
+// run is the shared state of a load test
+type run struct {
+	broadcast latencies
+	ack       latencies
+
+	mu        sync.Mutex
+	opened    map[int]time.Time
+	responses map[int]int
+	correct   int
+	accepted  int
+	errors    map[string]int
+	failed    int
+	lost      int
+	notify    chan struct{}
+}
+
+func newRun() *run {
+	return &run{
+		opened:    make(map[int]time.Time),
+		responses: make(map[int]int),
+		errors:    make(map[string]int),
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// open records when the host asked to open a question
+func (r *run) open(questionIndex int) {
+	r.mu.Lock()
+	r.opened[questionIndex] = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *run) openedAt(questionIndex int) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.opened[questionIndex]
+}
+
+func (r *run) answered(questionIndex int, isCorrect bool) {
+	r.mu.Lock()
+	r.accepted++
+	if isCorrect {
+		r.correct++
+	}
+	r.responses[questionIndex]++
+	r.mu.Unlock()
+
+	r.wake()
+}
+
+func (r *run) rejected(questionIndex int, reason string) {
+	r.mu.Lock()
+	r.errors[reason]++
+	r.responses[questionIndex]++
+	r.mu.Unlock()
+
+	r.wake()
+}
+
+func (r *run) failedJoin() {
+	r.mu.Lock()
+	r.failed++
+	r.mu.Unlock()
+}
+
+func (r *run) joinFailures() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failed
+}
+
+func (r *run) disconnected() {
+	r.mu.Lock()
+	r.lost++
+	r.mu.Unlock()
+}
+
+func (r *run) wake() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// waitAnswers waits until every player responded to the question
+// or the timeout passes and returns how many responded
+func (r *run) waitAnswers(questionIndex, players int, timeout time.Duration) int {
+	deadline := time.After(timeout)
+
+	for {
+		r.mu.Lock()
+		responses := r.responses[questionIndex]
+		r.mu.Unlock()
+
+		if responses >= players {
+			return responses
+		}
+
+		select {
+		case <-r.notify:
+		case <-deadline:
+			return responses
+		}
+	}
+}
+
+func (r *run) report(players int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Println()
+	fmt.Printf("Players joined:         %d/%d\n", players-r.failed, players)
+	fmt.Printf("Connections lost:       %d\n", r.lost)
+	fmt.Printf("Questions opened:       %d\n", len(r.opened)-1)
+
+	correctShare := 0.0
+	if r.accepted > 0 {
+		correctShare = float64(r.correct) / float64(r.accepted) * 100
+	}
+	fmt.Printf("Answers accepted:       %d (%.1f%% correct)\n", r.accepted, correctShare)
+
+	rejected := 0
+	for _, count := range r.errors {
+		rejected += count
+	}
+	fmt.Printf("Answers rejected:       %d\n", rejected)
+	for reason, count := range r.errors {
+		fmt.Printf("  %-40s %d\n", reason, count)
+	}
+
+	fmt.Println()
+	fmt.Printf("Question broadcast:     %s\n", r.broadcast.summary())
+	fmt.Printf("Answer acknowledgement: %s\n", r.ack.summary())
+}
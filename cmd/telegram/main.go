@@ -1,7 +1,8 @@
 package main
 
 import (
-	"kahoot_bsu/internal/service/telegram"
+	"context"
+	"kahoot_bsu/internal/app/telegram"
 )
 
 func main() {
@@ -13,5 +14,5 @@ func main() {
 		}
 	}()
 
-	telegram.Start(app)
+	telegram.Start(context.Background(), app)
 }
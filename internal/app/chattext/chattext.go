@@ -0,0 +1,48 @@
+// Package chattext formats the texts the bot sends to chats
+package chattext
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// LeaderboardSize is how many players are listed in the results
+const LeaderboardSize = 10
+
+// Place is a player in the leaderboard, MaxScore is left out when zero
+type Place struct {
+	Login    string
+	Score    int
+	MaxScore int
+}
+
+// Leaderboard lists the best players in HTML, a line each with medals for
+// the first three. The places come ordered by score.
+func Leaderboard(places []Place) string {
+	medals := []string{"🥇", "🥈", "🥉"}
+
+	var text strings.Builder
+	for i, p := range places[:min(len(places), LeaderboardSize)] {
+		place := fmt.Sprintf("%d.", i+1)
+		if i < len(medals) {
+			place = medals[i]
+		}
+		fmt.Fprintf(&text, "%s %s — %d", place, html.EscapeString(p.Login), p.Score)
+		if p.MaxScore > 0 {
+			fmt.Fprintf(&text, " из %d", p.MaxScore)
+		}
+		text.WriteString("\n")
+	}
+
+	return text.String()
+}
+
+// Truncate cuts text to length runes, the last one becomes an ellipsis
+func Truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "…"
+}
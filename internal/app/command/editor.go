@@ -0,0 +1,76 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"kahoot_bsu/internal/app/editor"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/service/fsm"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// editorCommand is the base of the commands that open the quiz editor
+type editorCommand struct {
+	*CommandHandler
+	editor *editor.Service
+	fsm    *fsm.FSMContext
+}
+
+type NewQuizCommand struct {
+	editorCommand
+}
+
+func NewNewQuizCommand(commandHandler *CommandHandler, editor *editor.Service, fsm *fsm.FSMContext) *NewQuizCommand {
+	return &NewQuizCommand{editorCommand{CommandHandler: commandHandler, editor: editor, fsm: fsm}}
+}
+
+// Execute starts a new quiz in the editor
+func (h *NewQuizCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	err := h.editor.Create(ctx, h.fsm, h.bot, message.From.ID)
+	h.replyError(message, err)
+}
+
+type EditQuizCommand struct {
+	editorCommand
+}
+
+func NewEditQuizCommand(commandHandler *CommandHandler, editor *editor.Service, fsm *fsm.FSMContext) *EditQuizCommand {
+	return &EditQuizCommand{editorCommand{CommandHandler: commandHandler, editor: editor, fsm: fsm}}
+}
+
+// Execute opens the quiz from "/editquiz <quiz ID>" in the editor
+func (h *EditQuizCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	quizID := strings.TrimSpace(message.CommandArguments())
+	if quizID == "" {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Укажите ID викторины: /editquiz <ID>. Ваши викторины: /myquizzes")
+		h.bot.Telegram.Send(msg)
+		return
+	}
+
+	err := h.editor.Edit(ctx, h.fsm, h.bot, message.From.ID, quizID)
+	h.replyError(message, err)
+}
+
+// replyError explains to the teacher why the editor did not open
+func (h *editorCommand) replyError(message *tgbotapi.Message, err error) {
+	if err == nil {
+		return
+	}
+
+	var quizNotFoundErr quiz.QuizNotFoundError
+
+	text := "Не удалось открыть редактор, попробуйте позже."
+	switch {
+	case errors.Is(err, editor.ErrNotTeacher):
+		text = "Эта команда доступна только преподавателям."
+	case errors.Is(err, editor.ErrNotOwner), errors.As(err, &quizNotFoundErr):
+		text = "Викторина не найдена."
+	default:
+		h.log.Error("Failed to run editor command", "command", message.Command(), "error", err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	h.bot.Telegram.Send(msg)
+}
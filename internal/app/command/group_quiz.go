@@ -0,0 +1,74 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"kahoot_bsu/internal/app/group"
+	"kahoot_bsu/internal/auth"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/ports"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type GroupQuizCommand struct {
+	*CommandHandler
+	group    *group.Service
+	userRepo ports.UserRepository
+}
+
+func NewGroupQuizCommand(commandHandler *CommandHandler, group *group.Service, userRepo ports.UserRepository) *GroupQuizCommand {
+	return &GroupQuizCommand{
+		CommandHandler: commandHandler,
+		group:          group,
+		userRepo:       userRepo,
+	}
+}
+
+// Execute runs the quiz from "/groupquiz <quiz ID>" in the group chat as quiz polls
+func (h *GroupQuizCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	if !message.Chat.IsGroup() && !message.Chat.IsSuperGroup() {
+		h.reply(message, "Эта команда работает только в групповых чатах.")
+		return
+	}
+
+	quizID := strings.TrimSpace(message.CommandArguments())
+	if quizID == "" {
+		h.reply(message, "Укажите викторину: /groupquiz <ID викторины>")
+		return
+	}
+
+	user, err := h.userRepo.UserByTelegramID(ctx, message.From.ID)
+	if err != nil || !auth.New(user).IsTeacher() {
+		h.reply(message, "Запускать викторины в группах могут только преподаватели.")
+		return
+	}
+
+	err = h.group.Start(ctx, message.Chat.ID, user.ID, quizID)
+
+	var (
+		quizNotFoundErr quiz.QuizNotFoundError
+		unsupportedErr  group.UnsupportedQuestionError
+	)
+	switch {
+	case err == nil:
+		h.reply(message, "🎯 Викторина начинается! Отвечайте на опросы, итоги будут в конце.")
+	case errors.Is(err, group.ErrGameRunning):
+		h.reply(message, "В этом чате уже идёт викторина.")
+	case errors.As(err, &quizNotFoundErr):
+		h.reply(message, "Викторина не найдена.")
+	case errors.Is(err, group.ErrPrivateQuiz):
+		h.reply(message, "Эта викторина закрыта, провести её может только автор.")
+	case errors.As(err, &unsupportedErr):
+		h.reply(message, "Викторину нельзя провести опросами: "+unsupportedErr.Error())
+	default:
+		h.log.Error("Failed to start group quiz", "quiz_id", quizID, "error", err)
+		h.reply(message, "Не удалось запустить викторину, попробуйте позже.")
+	}
+}
+
+func (h *GroupQuizCommand) reply(message *tgbotapi.Message, text string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	h.bot.Telegram.Send(msg)
+}
@@ -2,16 +2,19 @@ package command
 
 import (
 	"kahoot_bsu/internal/domain/models"
+	"log/slog"
 ) 
 
 type CommandHandler struct {
 	bot *models.Bot
 	WebAppUrl string
+	log *slog.Logger
 }
 
-func New(bot *models.Bot, WebAppUrl string) *CommandHandler {
+func New(bot *models.Bot, WebAppUrl string, log *slog.Logger) *CommandHandler {
 	return &CommandHandler{
 		bot: bot, 
 		WebAppUrl: WebAppUrl,
+		log: log,
 	}
 }
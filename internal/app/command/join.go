@@ -0,0 +1,109 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/ports"
+	"kahoot_bsu/internal/service/game"
+	"net/url"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type JoinCommand struct {
+	*CommandHandler
+	game     *game.Service
+	userRepo ports.UserRepository
+}
+
+func NewJoinCommand(commandHandler *CommandHandler, game *game.Service, userRepo ports.UserRepository) *JoinCommand {
+	return &JoinCommand{
+		CommandHandler: commandHandler,
+		game:           game,
+		userRepo:       userRepo,
+	}
+}
+
+// Execute joins the game with the code from "/join <code>"
+func (h *JoinCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	joinCode := strings.TrimSpace(message.CommandArguments())
+	if joinCode == "" {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "🎮 Отправьте код игры, например: /join ABC123")
+		h.bot.Telegram.Send(msg)
+		return
+	}
+
+	h.Join(ctx, message, joinCode)
+}
+
+// Join registers the sender as a participant of the session with the join code
+// and sends them the Mini App button bound to their participant
+func (h *JoinCommand) Join(ctx context.Context, message *tgbotapi.Message, joinCode string) {
+	// Mini App buttons only work in private chats
+	if !message.Chat.IsPrivate() {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Чтобы присоединиться к игре, напишите боту в личные сообщения.")
+		h.bot.Telegram.Send(msg)
+		return
+	}
+
+	// Registered users play under their account, others under their Telegram name
+	var userID *int64
+	login := models.DisplayName(message.From)
+	if user, err := h.userRepo.UserByTelegramID(ctx, message.From.ID); err == nil {
+		userID = &user.ID
+		login = user.Login
+	}
+
+	// Joining again returns the participant the user already has
+	participant, err := h.game.JoinTelegram(ctx, strings.ToUpper(joinCode), message.From.ID, userID, login)
+
+	var sessionNotFoundErr session.SessionNotFoundError
+	switch {
+	case errors.As(err, &sessionNotFoundErr):
+		h.reply(message, "Игра с кодом "+joinCode+" не найдена.")
+		return
+	case errors.Is(err, game.ErrSessionFinished):
+		h.reply(message, "Эта игра уже окончена.")
+		return
+	case err != nil:
+		h.log.Error("Failed to join session", "join_code", joinCode, "error", err)
+		h.reply(message, "Не удалось присоединиться к игре, попробуйте позже.")
+		return
+	}
+
+	if h.WebAppUrl == "" {
+		h.reply(message, "✅ Вы в игре! Играть можно прямо в чате: /play "+joinCode)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "✅ Вы в игре! Нажмите на кнопку ниже, чтобы открыть викторину.")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonWebApp("Играть", tgbotapi.WebAppInfo{URL: participantURL(h.WebAppUrl, participant)}),
+		),
+	)
+	h.bot.Telegram.Send(msg)
+}
+
+func (h *JoinCommand) reply(message *tgbotapi.Message, text string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	h.bot.Telegram.Send(msg)
+}
+
+// participantURL binds the Mini App to the session and participant
+func participantURL(webAppURL string, participant *session.Participant) string {
+	u, err := url.Parse(webAppURL)
+	if err != nil {
+		return webAppURL
+	}
+
+	query := u.Query()
+	query.Set("session_id", participant.SessionID)
+	query.Set("participant_id", participant.ID)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
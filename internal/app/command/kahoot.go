@@ -1,12 +1,16 @@
 package command
 
-import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
 
 type KahootComand struct {
 	*CommandHandler
 }
 
-func (h *KahootComand) Execute(message *tgbotapi.Message) {
+func (h *KahootComand) Execute(ctx context.Context, message *tgbotapi.Message) {
 	kahootMsgText := "Нажмите на кнопку ниже, чтобы запустить приложение"
 	kbRow := tgbotapi.NewInlineKeyboardRow(
 		tgbotapi.NewInlineKeyboardButtonWebApp("Kahoot!", tgbotapi.WebAppInfo{URL: h.WebAppUrl}),
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"kahoot_bsu/internal/app/play"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/service/game"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type PlayCommand struct {
+	*CommandHandler
+	play *play.Service
+}
+
+func NewPlayCommand(commandHandler *CommandHandler, play *play.Service) *PlayCommand {
+	return &PlayCommand{
+		CommandHandler: commandHandler,
+		play:           play,
+	}
+}
+
+// Execute joins the game with the code from "/play <code>" right in the chat
+func (h *PlayCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	joinCode := strings.TrimSpace(message.CommandArguments())
+	if joinCode == "" {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "🎮 Отправьте код игры, например: /play ABC123")
+		h.bot.Telegram.Send(msg)
+		return
+	}
+
+	_, err := h.play.Join(ctx, message.Chat.ID, message.From.ID, models.DisplayName(message.From), joinCode)
+
+	var sessionNotFoundErr session.SessionNotFoundError
+	text := "✅ Вы в игре! Вопросы придут в этот чат, как только ведущий их откроет."
+	switch {
+	case errors.As(err, &sessionNotFoundErr):
+		text = "Игра с кодом " + joinCode + " не найдена."
+	case errors.Is(err, game.ErrSessionFinished):
+		text = "Эта игра уже окончена."
+	case err != nil:
+		h.log.Error("Failed to join session", "join_code", joinCode, "error", err)
+		text = "Не удалось присоединиться к игре, попробуйте позже."
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	h.bot.Telegram.Send(msg)
+}
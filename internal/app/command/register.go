@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"kahoot_bsu/internal/service/fsm"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -8,22 +9,25 @@ import (
 
 type RegisterCommand struct {
 	*CommandHandler
-	fsm *fsm.FSMContext
+	fsm   *fsm.FSMContext
+	scene *fsm.Scene
 }
 
 func NewRegisterCommand(
 	commandHandler *CommandHandler,
 	fsm *fsm.FSMContext,
+	scene *fsm.Scene,
 ) *RegisterCommand {
 	return &RegisterCommand{
 		CommandHandler: commandHandler,
 		fsm:            fsm,
+		scene:          scene,
 	}
 }
 
-func (h *RegisterCommand) Execute(message *tgbotapi.Message) {
-	msg := tgbotapi.NewMessage(message.Chat.ID, "🔑 Пожалуйста, введите ваш login для регистрации.")
-
-	h.bot.Telegram.Send(msg)
-	h.fsm.Set(fsm.StateAwaitingLogin)
+func (h *RegisterCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	if err := h.scene.Enter(h.fsm, h.bot); err != nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Не удалось начать регистрацию. Попробуйте позже.")
+		h.bot.Telegram.Send(msg)
+	}
 }
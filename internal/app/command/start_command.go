@@ -1,12 +1,23 @@
 package command
 
-import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
 
 type StartCommand struct {
 	*CommandHandler
+	Join *JoinCommand
 }
 
-func (c *StartCommand) Execute(message *tgbotapi.Message) {
+// Execute greets the user, "/start <join code>" comes from a t.me/<bot>?start=<join code> link
+func (c *StartCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	if joinCode := message.CommandArguments(); joinCode != "" && c.Join != nil {
+		c.Join.Join(ctx, message, joinCode)
+		return
+	}
+
 	welcomeText := "👋 Добро пожаловать! Пожалуйста, зарегистрируйтесь, отправив команду /register."
 	msg := tgbotapi.NewMessage(message.Chat.ID, welcomeText)
 	c.bot.Telegram.Send(msg)
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"kahoot_bsu/internal/app/host"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/domain/models/session"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// teacherCommand is the base of the commands that only teachers may run
+type teacherCommand struct {
+	*CommandHandler
+	host *host.Service
+}
+
+type MyQuizzesCommand struct {
+	teacherCommand
+}
+
+func NewMyQuizzesCommand(commandHandler *CommandHandler, host *host.Service) *MyQuizzesCommand {
+	return &MyQuizzesCommand{teacherCommand{CommandHandler: commandHandler, host: host}}
+}
+
+// Execute lists the teacher's quizzes page by page
+func (h *MyQuizzesCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	err := h.host.Quizzes(ctx, message.Chat.ID, message.From.ID)
+	h.replyError(message, err)
+}
+
+type HostCommand struct {
+	teacherCommand
+}
+
+func NewHostCommand(commandHandler *CommandHandler, host *host.Service) *HostCommand {
+	return &HostCommand{teacherCommand{CommandHandler: commandHandler, host: host}}
+}
+
+// Execute starts a game of the quiz from "/host <quiz ID>",
+// without an ID the teacher picks a quiz from the list
+func (h *HostCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	quizID := strings.TrimSpace(message.CommandArguments())
+	if quizID == "" {
+		h.replyError(message, h.host.Quizzes(ctx, message.Chat.ID, message.From.ID))
+		return
+	}
+
+	h.replyError(message, h.host.Host(ctx, message.Chat.ID, message.From.ID, quizID))
+}
+
+type ResultsCommand struct {
+	teacherCommand
+}
+
+func NewResultsCommand(commandHandler *CommandHandler, host *host.Service) *ResultsCommand {
+	return &ResultsCommand{teacherCommand{CommandHandler: commandHandler, host: host}}
+}
+
+// Execute sends the results of the game from "/results <session ID>",
+// without an ID the teacher picks one of the recent games
+func (h *ResultsCommand) Execute(ctx context.Context, message *tgbotapi.Message) {
+	sessionID := strings.TrimSpace(message.CommandArguments())
+	err := h.host.Results(ctx, message.Chat.ID, message.From.ID, sessionID)
+	h.replyError(message, err)
+}
+
+// replyError explains to the teacher why the command failed
+func (h *teacherCommand) replyError(message *tgbotapi.Message, err error) {
+	if err == nil {
+		return
+	}
+
+	var (
+		quizNotFoundErr    quiz.QuizNotFoundError
+		sessionNotFoundErr session.SessionNotFoundError
+	)
+
+	text := "Не удалось выполнить команду, попробуйте позже."
+	switch {
+	case errors.Is(err, host.ErrNotTeacher):
+		text = "Эта команда доступна только преподавателям."
+	case errors.Is(err, host.ErrNotHost), errors.As(err, &sessionNotFoundErr):
+		text = "Игра не найдена."
+	case errors.As(err, &quizNotFoundErr):
+		text = "Викторина не найдена."
+	case errors.Is(err, host.ErrPrivateQuiz):
+		text = "Эта викторина закрыта, провести её может только автор."
+	default:
+		h.log.Error("Failed to run teacher command", "command", message.Command(), "error", err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	h.bot.Telegram.Send(msg)
+}
@@ -0,0 +1,812 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"kahoot_bsu/internal/app/chattext"
+	"kahoot_bsu/internal/auth"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/ports"
+	"kahoot_bsu/internal/service/fsm"
+	"kahoot_bsu/internal/service/markup"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+// States of the quiz editor, they form the "quiz" state group
+const (
+	StateTitle      models.State = "quiz:title"
+	StateVisibility models.State = "quiz:visibility"
+	StateQuestion   models.State = "quiz:question"
+	StateOptions    models.State = "quiz:options"
+	StateCorrect    models.State = "quiz:correct"
+	StateTimeLimit  models.State = "quiz:time_limit"
+	StatePoints     models.State = "quiz:points"
+	StatePreview    models.State = "quiz:preview"
+	StateImport     models.State = "quiz:import"
+)
+
+// stateGroup is the group of the editor states
+const stateGroup models.StateGroup = "quiz"
+
+// CallbackPrefix marks the callback data of the editor buttons
+const CallbackPrefix = "quiz:"
+
+// Callback data kinds, "quiz:<kind>:<argument>"
+const (
+	callbackVisibility = "v" // public or private
+	callbackCorrect    = "c" // index of the correct option
+	callbackTimeLimit  = "t" // seconds
+	callbackPoints     = "p" // points
+	callbackAction     = "a" // preview action
+	callbackDelete     = "d" // index of the question to delete
+	callbackImport     = "i" // import action
+)
+
+// Preview actions
+const (
+	actionAdd    = "add"
+	actionRename = "title"
+	actionSave   = "save"
+	actionCancel = "cancel"
+)
+
+// Import actions
+const (
+	importCreate = "create"
+	importEdit   = "edit"
+	importCancel = "cancel"
+)
+
+const (
+	maxTitle   = 255
+	minOptions = 2
+	// maxOptions is the limit of Telegram polls used by group games
+	maxOptions = 10
+
+	minTimeLimit = 5
+	maxTimeLimit = 600
+	maxPoints    = 10000
+
+	// maxPreview keeps the preview under the Telegram message limit
+	maxPreview = 3800
+
+	// maxButtonText keeps options readable on a phone
+	maxButtonText = 40
+
+	// deleteButtonsPerRow is how many delete buttons share a row of the preview
+	deleteButtonsPerRow = 5
+)
+
+var (
+	ErrNotTeacher = errors.New("only teachers can edit quizzes")
+	ErrNotOwner   = errors.New("the quiz belongs to another user")
+)
+
+// Draft is the quiz being edited, it is kept in the FSM data between the steps
+type Draft struct {
+	// QuizID is empty until a new quiz is saved
+	QuizID    string              `json:"quiz_id,omitempty"`
+	Title     string              `json:"title"`
+	IsPublic  bool                `json:"is_public"`
+	Questions []question.Question `json:"questions"`
+	// Deleted are the saved questions removed from the quiz
+	Deleted []string `json:"deleted,omitempty"`
+	// Current is the question being added
+	Current *question.Question `json:"current,omitempty"`
+}
+
+var draftData = fsm.NewData[Draft]("quiz_draft")
+
+// Service is a bot dialog that creates and edits quizzes: the title and
+// visibility, then questions with their options, time limit and points,
+// and a preview before the quiz is saved
+type Service struct {
+	quizRepo     quiz.Repository
+	questionRepo question.Repository
+	users        ports.UserRepository
+	tx           ports.Transactor
+	client       *http.Client
+	log          *slog.Logger
+}
+
+func NewService(
+	quizRepo quiz.Repository,
+	questionRepo question.Repository,
+	users ports.UserRepository,
+	tx ports.Transactor,
+	log *slog.Logger,
+) *Service {
+	return &Service{
+		quizRepo:     quizRepo,
+		questionRepo: questionRepo,
+		users:        users,
+		tx:           tx,
+		client:       &http.Client{Timeout: downloadTimeout},
+		log:          log,
+	}
+}
+
+// Register registers the handlers of the editor and declares its transitions
+func (s *Service) Register(router *fsm.Router) {
+	router.StateGroup(stateGroup).Command(s.cancel, fsm.Command("cancel"))
+
+	router.State(StateTitle).Message(s.handleTitle)
+	router.State(StateVisibility).Callback(s.handleVisibility, callbackFilter(callbackVisibility))
+	router.State(StateQuestion).Message(s.handleQuestion)
+	router.State(StateOptions).Message(s.handleOptions)
+	router.State(StateCorrect).Callback(s.handleCorrect, callbackFilter(callbackCorrect))
+	router.State(StateTimeLimit).Callback(s.handleTimeLimitButton, callbackFilter(callbackTimeLimit))
+	router.State(StateTimeLimit).Message(s.handleTimeLimit)
+	router.State(StatePoints).Callback(s.handlePointsButton, callbackFilter(callbackPoints))
+	router.State(StatePoints).Message(s.handlePoints)
+	router.State(StatePreview).Callback(s.handleAction, callbackFilter(callbackAction))
+	router.State(StatePreview).Callback(s.handleDelete, callbackFilter(callbackDelete))
+	router.State(StateImport).Document(s.handleDocument)
+	router.State(StateImport).Callback(s.handleImport, callbackFilter(callbackImport))
+	// Files are imported outside of the dialogs only, a document sent during one is its answer
+	for _, state := range []models.State{fsm.DefaultState, fsm.StateRegistered} {
+		router.State(state).Document(s.handleDocument, fsm.ChatType("private"))
+		router.Transition(state, StateImport, "document")
+	}
+
+	router.Transition(fsm.DefaultState, StateTitle, "/newquiz")
+	router.Transition(fsm.DefaultState, StatePreview, "/editquiz")
+	router.Transition(StateTitle, StateVisibility, "title")
+	router.Transition(StateVisibility, StateQuestion, "visibility")
+	router.Transition(StateVisibility, StatePreview, "visibility")
+	router.Transition(StateQuestion, StateOptions, "question")
+	router.Transition(StateOptions, StateCorrect, "options")
+	router.Transition(StateCorrect, StateTimeLimit, "correct")
+	router.Transition(StateTimeLimit, StatePoints, "time limit")
+	router.Transition(StatePoints, StatePreview, "points")
+	router.Transition(StatePreview, StateQuestion, actionAdd)
+	router.Transition(StatePreview, StateTitle, actionRename)
+	router.Transition(StatePreview, fsm.DefaultState, actionSave)
+	router.Transition(fsm.DefaultState, StatePreview, "save failed")
+	router.Transition(StateImport, StatePreview, importEdit)
+	router.Transition(StateImport, fsm.DefaultState, importCreate)
+	router.Transition(StateImport, fsm.DefaultState, importCancel)
+	router.Transition(fsm.DefaultState, StateImport, "import failed")
+	for _, state := range []models.State{StateTitle, StateVisibility, StateQuestion, StateOptions, StateCorrect, StateTimeLimit, StatePoints, StatePreview, StateImport} {
+		router.Transition(state, fsm.DefaultState, "/cancel")
+	}
+}
+
+// Create starts a new quiz
+func (s *Service) Create(ctx context.Context, fsmCtx *fsm.FSMContext, bot *models.Bot, telegramID int64) error {
+	if _, err := s.teacher(ctx, telegramID); err != nil {
+		return err
+	}
+
+	if err := fsmCtx.ClearData(); err != nil {
+		return err
+	}
+	if err := draftData.Set(fsmCtx, Draft{}); err != nil {
+		return err
+	}
+	if err := fsmCtx.Set(StateTitle); err != nil {
+		return err
+	}
+
+	return send(bot, fsmCtx.ChatID(), "📝 Новая викторина. Введите название:\n\n/cancel — отменить")
+}
+
+// Edit opens the preview of a saved quiz of the teacher
+func (s *Service) Edit(ctx context.Context, fsmCtx *fsm.FSMContext, bot *models.Bot, telegramID int64, quizID string) error {
+	teacher, err := s.teacher(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+
+	q, err := s.quizRepo.Quiz(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if q.UserID != strconv.FormatInt(teacher.ID, 10) {
+		return ErrNotOwner
+	}
+
+	draft := Draft{
+		QuizID:    q.ID,
+		Title:     q.Title,
+		IsPublic:  q.IsPublic,
+		Questions: q.Questions,
+	}
+
+	if err := fsmCtx.ClearData(); err != nil {
+		return err
+	}
+	if err := draftData.Set(fsmCtx, draft); err != nil {
+		return err
+	}
+	if err := fsmCtx.Set(StatePreview); err != nil {
+		return err
+	}
+
+	return s.sendPreview(bot, fsmCtx.ChatID(), draft)
+}
+
+func (s *Service) handleTitle(ctx context.Context, fsmCtx *fsm.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	title := strings.TrimSpace(message.Text)
+	if title == "" || len([]rune(title)) > maxTitle {
+		return send(bot, message.Chat.ID, fmt.Sprintf("Название должно быть от 1 до %d символов. Введите название:", maxTitle))
+	}
+
+	err := s.update(fsmCtx, StateVisibility, func(draft *Draft) error {
+		draft.Title = title
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "Кто может запускать викторину?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔒 Только я", callbackData(callbackVisibility, "private")),
+		tgbotapi.NewInlineKeyboardButtonData("🌐 Все", callbackData(callbackVisibility, "public")),
+	))
+	_, err = bot.Telegram.Send(msg)
+	return err
+}
+
+func (s *Service) handleVisibility(ctx context.Context, fsmCtx *fsm.FSMContext, query *tgbotapi.CallbackQuery, bot *models.Bot) error {
+	if err := answer(bot, query); err != nil {
+		return err
+	}
+
+	draft, err := s.draft(fsmCtx)
+	if err != nil {
+		return err
+	}
+
+	// A quiz with questions goes back to the preview after it is renamed
+	next := StateQuestion
+	if len(draft.Questions) > 0 {
+		next = StatePreview
+	}
+
+	draft.IsPublic = callbackArgument(query.Data) == "public"
+	if err := s.save(fsmCtx, next, draft); err != nil {
+		return err
+	}
+
+	if next == StatePreview {
+		return s.sendPreview(bot, query.Message.Chat.ID, draft)
+	}
+	return s.askQuestion(bot, query.Message.Chat.ID, draft)
+}
+
+func (s *Service) handleQuestion(ctx context.Context, fsmCtx *fsm.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	text := strings.TrimSpace(message.Text)
+	if text == "" {
+		return send(bot, message.Chat.ID, "Введите текст вопроса:")
+	}
+
+	text, err := markup.Sanitize(text)
+	var markupErr markup.InvalidMarkupError
+	if errors.As(err, &markupErr) {
+		return send(bot, message.Chat.ID, fmt.Sprintf("Ошибка в разметке: %s. Введите текст вопроса:", markupErr.Reason))
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.update(fsmCtx, StateOptions, func(draft *Draft) error {
+		draft.Current = &question.Question{Text: text}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return send(bot, message.Chat.ID, fmt.Sprintf("Отправьте варианты ответа, каждый с новой строки (от %d до %d):", minOptions, maxOptions))
+}
+
+func (s *Service) handleOptions(ctx context.Context, fsmCtx *fsm.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	var options []question.Option
+	for _, line := range strings.Split(message.Text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		text, err := markup.Sanitize(line)
+		var markupErr markup.InvalidMarkupError
+		if errors.As(err, &markupErr) {
+			return send(bot, message.Chat.ID, fmt.Sprintf("Ошибка в разметке варианта %d: %s. Отправьте варианты ответа:", len(options)+1, markupErr.Reason))
+		}
+		if err != nil {
+			return err
+		}
+		options = append(options, question.Option{Text: text, Position: len(options)})
+	}
+
+	if len(options) < minOptions || len(options) > maxOptions {
+		return send(bot, message.Chat.ID, fmt.Sprintf("Нужно от %d до %d вариантов, каждый с новой строки. Отправьте варианты ответа:", minOptions, maxOptions))
+	}
+
+	err := s.update(fsmCtx, StateCorrect, func(draft *Draft) error {
+		if draft.Current == nil {
+			return errors.New("no question is being added")
+		}
+		draft.Current.Options = options
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(options))
+	for i, option := range options {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(chattext.Truncate(option.Text, maxButtonText), callbackData(callbackCorrect, strconv.Itoa(i))),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "Отметьте правильный ответ:")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	_, err = bot.Telegram.Send(msg)
+	return err
+}
+
+func (s *Service) handleCorrect(ctx context.Context, fsmCtx *fsm.FSMContext, query *tgbotapi.CallbackQuery, bot *models.Bot) error {
+	if err := answer(bot, query); err != nil {
+		return err
+	}
+
+	index, err := strconv.Atoi(callbackArgument(query.Data))
+	if err != nil {
+		return fmt.Errorf("invalid correct option: %w", err)
+	}
+
+	err = s.update(fsmCtx, StateTimeLimit, func(draft *Draft) error {
+		if draft.Current == nil || index < 0 || index >= len(draft.Current.Options) {
+			return fmt.Errorf("invalid correct option: %d", index)
+		}
+		for i := range draft.Current.Options {
+			draft.Current.Options[i].IsCorrect = i == index
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(query.Message.Chat.ID, fmt.Sprintf("Время на ответ в секундах (от %d до %d):", minTimeLimit, maxTimeLimit))
+	msg.ReplyMarkup = numberKeyboard(callbackTimeLimit, 10, 20, 30, 60)
+	_, err = bot.Telegram.Send(msg)
+	return err
+}
+
+func (s *Service) handleTimeLimitButton(ctx context.Context, fsmCtx *fsm.FSMContext, query *tgbotapi.CallbackQuery, bot *models.Bot) error {
+	if err := answer(bot, query); err != nil {
+		return err
+	}
+	return s.setTimeLimit(fsmCtx, bot, query.Message.Chat.ID, callbackArgument(query.Data))
+}
+
+func (s *Service) handleTimeLimit(ctx context.Context, fsmCtx *fsm.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	return s.setTimeLimit(fsmCtx, bot, message.Chat.ID, message.Text)
+}
+
+func (s *Service) setTimeLimit(fsmCtx *fsm.FSMContext, bot *models.Bot, chatID int64, text string) error {
+	seconds, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || seconds < minTimeLimit || seconds > maxTimeLimit {
+		return send(bot, chatID, fmt.Sprintf("Введите число секунд от %d до %d:", minTimeLimit, maxTimeLimit))
+	}
+
+	err = s.update(fsmCtx, StatePoints, func(draft *Draft) error {
+		if draft.Current == nil {
+			return errors.New("no question is being added")
+		}
+		draft.Current.TimeLimit = seconds
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Сколько очков за правильный ответ (от 0 до %d)?", maxPoints))
+	msg.ReplyMarkup = numberKeyboard(callbackPoints, 0, 100, 200, 500)
+	_, err = bot.Telegram.Send(msg)
+	return err
+}
+
+func (s *Service) handlePointsButton(ctx context.Context, fsmCtx *fsm.FSMContext, query *tgbotapi.CallbackQuery, bot *models.Bot) error {
+	if err := answer(bot, query); err != nil {
+		return err
+	}
+	return s.setPoints(fsmCtx, bot, query.Message.Chat.ID, callbackArgument(query.Data))
+}
+
+func (s *Service) handlePoints(ctx context.Context, fsmCtx *fsm.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	return s.setPoints(fsmCtx, bot, message.Chat.ID, message.Text)
+}
+
+func (s *Service) setPoints(fsmCtx *fsm.FSMContext, bot *models.Bot, chatID int64, text string) error {
+	points, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || points < 0 || points > maxPoints {
+		return send(bot, chatID, fmt.Sprintf("Введите число очков от 0 до %d:", maxPoints))
+	}
+
+	draft, err := s.draft(fsmCtx)
+	if err != nil {
+		return err
+	}
+	if draft.Current == nil {
+		return errors.New("no question is being added")
+	}
+
+	current := *draft.Current
+	current.Points = points
+	if points == 0 {
+		// The repositories treat zero points as the default
+		current.PointsMode = question.PointsNone
+	}
+	draft.Questions = append(draft.Questions, current)
+	draft.Current = nil
+
+	if err := s.save(fsmCtx, StatePreview, draft); err != nil {
+		return err
+	}
+
+	return s.sendPreview(bot, chatID, draft)
+}
+
+func (s *Service) handleAction(ctx context.Context, fsmCtx *fsm.FSMContext, query *tgbotapi.CallbackQuery, bot *models.Bot) error {
+	chatID := query.Message.Chat.ID
+
+	switch callbackArgument(query.Data) {
+	case actionAdd:
+		if err := answer(bot, query); err != nil {
+			return err
+		}
+		draft, err := s.draft(fsmCtx)
+		if err != nil {
+			return err
+		}
+		if err := fsmCtx.Set(StateQuestion); err != nil {
+			return err
+		}
+		return s.askQuestion(bot, chatID, draft)
+
+	case actionRename:
+		if err := answer(bot, query); err != nil {
+			return err
+		}
+		if err := fsmCtx.Set(StateTitle); err != nil {
+			return err
+		}
+		return send(bot, chatID, "Введите новое название:")
+
+	case actionSave:
+		draft, err := s.draft(fsmCtx)
+		if err != nil {
+			return err
+		}
+		if len(draft.Questions) == 0 {
+			_, err := bot.Telegram.Request(tgbotapi.NewCallback(query.ID, "Добавьте хотя бы один вопрос"))
+			return err
+		}
+		if err := answer(bot, query); err != nil {
+			return err
+		}
+
+		// Leave the editor first, so a second tap on the button does not save the quiz twice
+		if err := fsmCtx.Set(fsm.DefaultState); err != nil {
+			return err
+		}
+
+		quizID, err := s.persist(ctx, query.From.ID, draft)
+		if err != nil {
+			s.log.Error("Failed to save quiz", "telegram_id", query.From.ID, "error", err)
+
+			// Back to the preview, the draft is still there
+			if err := fsmCtx.Set(StatePreview); err != nil {
+				return err
+			}
+			return send(bot, chatID, "Не удалось сохранить викторину, попробуйте ещё раз.")
+		}
+
+		if err := fsmCtx.ClearData(); err != nil {
+			return err
+		}
+		s.log.Info("Quiz saved in the bot", "quiz_id", quizID, "telegram_id", query.From.ID)
+
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Викторина «%s» сохранена.\nЗапустить игру: /host %s", html.EscapeString(draft.Title), quizID))
+		msg.ParseMode = tgbotapi.ModeHTML
+		_, err = bot.Telegram.Send(msg)
+		return err
+
+	case actionCancel:
+		if err := answer(bot, query); err != nil {
+			return err
+		}
+		return s.cancelDraft(fsmCtx, bot, chatID)
+	}
+
+	return fmt.Errorf("unknown editor action: %s", query.Data)
+}
+
+func (s *Service) handleDelete(ctx context.Context, fsmCtx *fsm.FSMContext, query *tgbotapi.CallbackQuery, bot *models.Bot) error {
+	if err := answer(bot, query); err != nil {
+		return err
+	}
+
+	index, err := strconv.Atoi(callbackArgument(query.Data))
+	if err != nil {
+		return fmt.Errorf("invalid question to delete: %w", err)
+	}
+
+	draft, err := s.draft(fsmCtx)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(draft.Questions) {
+		// A button of an outdated preview
+		return nil
+	}
+
+	if id := draft.Questions[index].ID; id != "" {
+		draft.Deleted = append(draft.Deleted, id)
+	}
+	draft.Questions = append(draft.Questions[:index], draft.Questions[index+1:]...)
+
+	if err := draftData.Set(fsmCtx, draft); err != nil {
+		return err
+	}
+
+	text, keyboard := preview(draft)
+	edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, keyboard)
+	edit.ParseMode = tgbotapi.ModeHTML
+	_, err = bot.Telegram.Send(edit)
+	return err
+}
+
+func (s *Service) cancel(ctx context.Context, fsmCtx *fsm.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	return s.cancelDraft(fsmCtx, bot, message.Chat.ID)
+}
+
+func (s *Service) cancelDraft(fsmCtx *fsm.FSMContext, bot *models.Bot, chatID int64) error {
+	if err := s.finish(fsmCtx); err != nil {
+		return err
+	}
+	return send(bot, chatID, "Редактирование отменено, изменения не сохранены.")
+}
+
+// persist saves the quiz and its new questions and deletes the removed ones
+func (s *Service) persist(ctx context.Context, telegramID int64, draft Draft) (string, error) {
+	teacher, err := s.teacher(ctx, telegramID)
+	if err != nil {
+		return "", err
+	}
+
+	q := &quiz.Quiz{
+		ID:        draft.QuizID,
+		UserID:    strconv.FormatInt(teacher.ID, 10),
+		Title:     draft.Title,
+		IsPublic:  draft.IsPublic,
+		CreatedBy: teacher.Login,
+	}
+	if q.ID == "" {
+		q.ID = uuid.NewString()
+	}
+
+	// A failed question must not leave the quiz half saved
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		return s.write(ctx, q, draft)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return q.ID, nil
+}
+
+// write saves the quiz with the changes of the draft
+func (s *Service) write(ctx context.Context, q *quiz.Quiz, draft Draft) error {
+	if err := s.quizRepo.UpdateOrCreate(ctx, q); err != nil {
+		return fmt.Errorf("failed to save quiz: %w", err)
+	}
+
+	for _, questionID := range draft.Deleted {
+		if err := s.questionRepo.Delete(ctx, questionID); err != nil {
+			return fmt.Errorf("failed to delete question: %w", err)
+		}
+	}
+
+	for _, draftQuestion := range draft.Questions {
+		if draftQuestion.ID != "" {
+			// Saved before
+			continue
+		}
+
+		newQuestion := draftQuestion
+		newQuestion.ID = uuid.NewString()
+		newQuestion.QuizID = q.ID
+		newQuestion.Options = make([]question.Option, len(draftQuestion.Options))
+		for i, option := range draftQuestion.Options {
+			option.ID = uuid.NewString()
+			newQuestion.Options[i] = option
+		}
+
+		if err := s.questionRepo.Create(ctx, &newQuestion); err != nil {
+			return fmt.Errorf("failed to save question: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// finish leaves the editor and drops the draft
+func (s *Service) finish(fsmCtx *fsm.FSMContext) error {
+	if err := fsmCtx.Set(fsm.DefaultState); err != nil {
+		return err
+	}
+	return fsmCtx.ClearData()
+}
+
+func (s *Service) draft(fsmCtx *fsm.FSMContext) (Draft, error) {
+	draft, ok, err := draftData.Get(fsmCtx)
+	if err != nil {
+		return Draft{}, err
+	}
+	if !ok {
+		return Draft{}, errors.New("quiz draft is missing")
+	}
+	return draft, nil
+}
+
+// update changes the draft and moves the editor to the next state
+func (s *Service) update(fsmCtx *fsm.FSMContext, next models.State, updateFn func(draft *Draft) error) error {
+	draft, err := s.draft(fsmCtx)
+	if err != nil {
+		return err
+	}
+	if err := updateFn(&draft); err != nil {
+		return err
+	}
+	return s.save(fsmCtx, next, draft)
+}
+
+// save moves the editor to the next state first, so a repeated update fails before the draft is written
+func (s *Service) save(fsmCtx *fsm.FSMContext, next models.State, draft Draft) error {
+	return fsmCtx.InTx(func(tx *fsm.FSMContext) error {
+		if err := tx.Set(next); err != nil {
+			return err
+		}
+		return draftData.Set(tx, draft)
+	})
+}
+
+func (s *Service) askQuestion(bot *models.Bot, chatID int64, draft Draft) error {
+	return send(bot, chatID, fmt.Sprintf("Вопрос %d. Введите текст вопроса:", len(draft.Questions)+1))
+}
+
+func (s *Service) sendPreview(bot *models.Bot, chatID int64, draft Draft) error {
+	text, keyboard := preview(draft)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = keyboard
+	_, err := bot.Telegram.Send(msg)
+	return err
+}
+
+// teacher returns the registered teacher with the Telegram ID
+func (s *Service) teacher(ctx context.Context, telegramID int64) (*models.User, error) {
+	user, err := s.users.UserByTelegramID(ctx, telegramID)
+	if err != nil {
+		var userNotFoundErr ports.UserNotFoundError
+		if errors.As(err, &userNotFoundErr) {
+			return nil, ErrNotTeacher
+		}
+		return nil, err
+	}
+
+	if !auth.New(user).IsTeacher() {
+		return nil, ErrNotTeacher
+	}
+
+	return user, nil
+}
+
+// preview renders the draft with the buttons that edit and save it
+func preview(draft Draft) (string, tgbotapi.InlineKeyboardMarkup) {
+	rows := [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("➕ Вопрос", callbackData(callbackAction, actionAdd)),
+		tgbotapi.NewInlineKeyboardButtonData("✏️ Название", callbackData(callbackAction, actionRename)),
+	)}
+
+	var deleteRow []tgbotapi.InlineKeyboardButton
+	for i := range draft.Questions {
+		deleteRow = append(deleteRow, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🗑 %d", i+1), callbackData(callbackDelete, strconv.Itoa(i))))
+		if len(deleteRow) == deleteButtonsPerRow || i == len(draft.Questions)-1 {
+			rows = append(rows, deleteRow)
+			deleteRow = nil
+		}
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("💾 Сохранить", callbackData(callbackAction, actionSave)),
+		tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", callbackData(callbackAction, actionCancel)),
+	))
+
+	return previewText(draft, maxPreview), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// previewText lists the questions of the draft in at most limit bytes
+func previewText(draft Draft, limit int) string {
+	var text strings.Builder
+
+	visibility := "🔒 Только я"
+	if draft.IsPublic {
+		visibility = "🌐 Все"
+	}
+	fmt.Fprintf(&text, "<b>%s</b>\n%s\n", html.EscapeString(draft.Title), visibility)
+
+	for i, q := range draft.Questions {
+		var item strings.Builder
+		fmt.Fprintf(&item, "\n%d. %s\n⏱ %d с · %d очков\n", i+1, html.EscapeString(q.Text), q.TimeLimit, q.Points)
+		for _, option := range q.Options {
+			mark := "▫️"
+			if option.IsCorrect {
+				mark = "✅"
+			}
+			fmt.Fprintf(&item, "%s %s\n", mark, html.EscapeString(option.Text))
+		}
+
+		if text.Len()+item.Len() > limit {
+			fmt.Fprintf(&text, "\n… и ещё %d", len(draft.Questions)-i)
+			break
+		}
+		text.WriteString(item.String())
+	}
+
+	if len(draft.Questions) == 0 {
+		text.WriteString("\nВопросов пока нет.")
+	}
+
+	return text.String()
+}
+
+func numberKeyboard(kind string, values ...int) tgbotapi.InlineKeyboardMarkup {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(values))
+	for _, value := range values {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(value), callbackData(kind, strconv.Itoa(value))))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+func send(bot *models.Bot, chatID int64, text string) error {
+	_, err := bot.Telegram.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+// answer stops the spinner of the tapped button
+func answer(bot *models.Bot, query *tgbotapi.CallbackQuery) error {
+	_, err := bot.Telegram.Request(tgbotapi.NewCallback(query.ID, ""))
+	return err
+}
+
+func callbackFilter(kind string) fsm.Filter {
+	return fsm.CallbackData("^" + CallbackPrefix + kind + ":")
+}
+
+func callbackData(kind, argument string) string {
+	return CallbackPrefix + kind + ":" + argument
+}
+
+func callbackArgument(data string) string {
+	_, argument, _ := strings.Cut(strings.TrimPrefix(data, CallbackPrefix), ":")
+	return argument
+}
@@ -0,0 +1,247 @@
+package editor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/service/fsm"
+	"kahoot_bsu/internal/service/quizimport"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxImportSize is far above any quiz, the limit of the Bot API is 20 MB
+	maxImportSize = 1 << 20
+
+	downloadTimeout = 30 * time.Second
+
+	// maxImportErrors is how many errors the summary lists
+	maxImportErrors = 20
+	// maxImportPreview leaves room for the errors in the summary
+	maxImportPreview = 2000
+)
+
+// handleDocument parses a quiz file sent by a teacher and asks to confirm the import
+func (s *Service) handleDocument(ctx context.Context, fsmCtx *fsm.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	chatID := message.Chat.ID
+	document := message.Document
+
+	if _, err := s.teacher(ctx, message.From.ID); err != nil {
+		if errors.Is(err, ErrNotTeacher) {
+			return send(bot, chatID, "Импортировать викторины могут только преподаватели.")
+		}
+		return err
+	}
+
+	format, err := quizimport.FormatOf(document.FileName)
+	if err != nil {
+		return send(bot, chatID, "Поддерживаются файлы CSV, JSON и GIFT (.csv, .json, .gift, .txt).")
+	}
+	if document.FileSize > maxImportSize {
+		return send(bot, chatID, fmt.Sprintf("Файл больше %d КБ, разделите викторину на несколько файлов.", maxImportSize>>10))
+	}
+
+	data, err := s.download(ctx, bot, document.FileID)
+	if err != nil {
+		s.log.Error("Failed to download quiz file", "telegram_id", message.From.ID, "error", err)
+		return send(bot, chatID, "Не удалось загрузить файл, попробуйте ещё раз.")
+	}
+
+	result, err := quizimport.Parse(format, document.FileName, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	summary := importErrors(document.FileName, result.Errors)
+	if len(result.Questions) == 0 {
+		return sendHTML(bot, chatID, summary+"В файле нет ни одного вопроса без ошибок, исправьте файл и отправьте его снова.", nil)
+	}
+
+	draft := Draft{Title: result.Title, Questions: result.Questions}
+	if err := fsmCtx.ClearData(); err != nil {
+		return err
+	}
+	if err := draftData.Set(fsmCtx, draft); err != nil {
+		return err
+	}
+	if err := fsmCtx.Set(StateImport); err != nil {
+		return err
+	}
+
+	if len(result.Errors) > 0 {
+		summary += "Вопросы с ошибками будут пропущены.\n\n"
+	}
+	summary += previewText(draft, maxImportPreview)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ Создать (%d)", len(draft.Questions)), callbackData(callbackImport, importCreate)),
+			tgbotapi.NewInlineKeyboardButtonData("✏️ Изменить", callbackData(callbackImport, importEdit)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", callbackData(callbackImport, importCancel)),
+		),
+	)
+	return sendHTML(bot, chatID, summary, keyboard)
+}
+
+// handleImport creates the imported quiz, opens it in the editor or drops it
+func (s *Service) handleImport(ctx context.Context, fsmCtx *fsm.FSMContext, query *tgbotapi.CallbackQuery, bot *models.Bot) error {
+	chatID := query.Message.Chat.ID
+
+	if err := answer(bot, query); err != nil {
+		return err
+	}
+
+	switch callbackArgument(query.Data) {
+	case importCreate:
+		draft, err := s.draft(fsmCtx)
+		if err != nil {
+			return err
+		}
+
+		// Leave the import first, so a second tap on the button does not create the quiz twice
+		if err := fsmCtx.Set(fsm.DefaultState); err != nil {
+			return err
+		}
+
+		quizID, err := s.create(ctx, query.From.ID, draft)
+		if err != nil {
+			s.log.Error("Failed to import quiz", "telegram_id", query.From.ID, "error", err)
+
+			if err := fsmCtx.Set(StateImport); err != nil {
+				return err
+			}
+			return send(bot, chatID, "Не удалось создать викторину, попробуйте ещё раз.")
+		}
+
+		if err := fsmCtx.ClearData(); err != nil {
+			return err
+		}
+		s.log.Info("Quiz imported in the bot", "quiz_id", quizID, "telegram_id", query.From.ID, "questions", len(draft.Questions))
+
+		return sendHTML(bot, chatID, fmt.Sprintf("✅ Викторина «%s» создана, вопросов: %d.\nЗапустить игру: /host %s\nИзменить: /editquiz %s",
+			html.EscapeString(draft.Title), len(draft.Questions), quizID, quizID), nil)
+
+	case importEdit:
+		draft, err := s.draft(fsmCtx)
+		if err != nil {
+			return err
+		}
+		if err := fsmCtx.Set(StatePreview); err != nil {
+			return err
+		}
+		return s.sendPreview(bot, chatID, draft)
+
+	case importCancel:
+		if err := s.finish(fsmCtx); err != nil {
+			return err
+		}
+		return send(bot, chatID, "Импорт отменён.")
+	}
+
+	return fmt.Errorf("unknown import action: %s", query.Data)
+}
+
+// create saves the imported quiz with its questions, owned by the teacher
+func (s *Service) create(ctx context.Context, telegramID int64, draft Draft) (string, error) {
+	teacher, err := s.teacher(ctx, telegramID)
+	if err != nil {
+		return "", err
+	}
+
+	q := &quiz.Quiz{
+		ID:        uuid.NewString(),
+		UserID:    strconv.FormatInt(teacher.ID, 10),
+		Title:     draft.Title,
+		IsPublic:  draft.IsPublic,
+		CreatedBy: teacher.Login,
+		Questions: make([]question.Question, len(draft.Questions)),
+	}
+
+	for i, draftQuestion := range draft.Questions {
+		newQuestion := draftQuestion
+		newQuestion.ID = uuid.NewString()
+		newQuestion.QuizID = q.ID
+		newQuestion.Options = make([]question.Option, len(draftQuestion.Options))
+		for j, option := range draftQuestion.Options {
+			option.ID = uuid.NewString()
+			option.QuestionID = newQuestion.ID
+			newQuestion.Options[j] = option
+		}
+		q.Questions[i] = newQuestion
+	}
+
+	if err := s.quizRepo.UpdateOrCreate(ctx, q); err != nil {
+		return "", fmt.Errorf("failed to create quiz: %w", err)
+	}
+
+	return q.ID, nil
+}
+
+// download reads a file sent to the bot
+func (s *Service) download(ctx context.Context, bot *models.Bot, fileID string) ([]byte, error) {
+	url, err := bot.Telegram.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxImportSize))
+}
+
+// importErrors is the summary of the errors in the file by line
+func importErrors(filename string, lineErrors []quizimport.LineError) string {
+	if len(lineErrors) == 0 {
+		return fmt.Sprintf("📥 Файл <b>%s</b> прочитан без ошибок.\n\n", html.EscapeString(filename))
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "📥 В файле <b>%s</b> ошибок: %d\n", html.EscapeString(filename), len(lineErrors))
+	for i, lineErr := range lineErrors {
+		if i == maxImportErrors {
+			fmt.Fprintf(&text, "… и ещё %d\n", len(lineErrors)-i)
+			break
+		}
+		fmt.Fprintf(&text, "Строка %d: %s\n", lineErr.Line, html.EscapeString(lineErr.Message))
+	}
+	text.WriteString("\n")
+
+	return text.String()
+}
+
+func sendHTML(bot *models.Bot, chatID int64, text string, keyboard any) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if keyboard != nil {
+		msg.ReplyMarkup = keyboard
+	}
+	_, err := bot.Telegram.Send(msg)
+	return err
+}
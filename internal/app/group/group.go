@@ -0,0 +1,355 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/app/chattext"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/service/game"
+	"kahoot_bsu/internal/service/markup"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Limits of Telegram quiz polls
+const (
+	maxPollQuestion   = 300
+	maxPollOption     = 100
+	minPollOptions    = 2
+	maxPollOptions    = 10
+	minPollOpenPeriod = 5 * time.Second
+	maxPollOpenPeriod = 600 * time.Second
+)
+
+const (
+	// defaultOpenPeriod is how long questions without a time limit are open
+	defaultOpenPeriod = 30 * time.Second
+
+	// closeDelay lets the last poll answers arrive before the next question
+	closeDelay = 2 * time.Second
+)
+
+var (
+	ErrGameRunning = errors.New("a game is already running in the chat")
+	ErrPrivateQuiz = errors.New("the quiz is private")
+)
+
+// UnsupportedQuestionError is returned for questions that cannot be sent as a quiz poll
+type UnsupportedQuestionError struct {
+	Position int
+	Reason   string
+}
+
+func (e UnsupportedQuestionError) Error() string {
+	return fmt.Sprintf("question %d cannot be sent as a quiz poll: %s", e.Position, e.Reason)
+}
+
+// chatGame is a game session played in a group chat
+type chatGame struct {
+	chatID    int64
+	session   *session.Session
+	questions []*question.Question
+
+	mu           sync.Mutex
+	participants map[int64]string // participant IDs by Telegram user ID
+}
+
+// openPoll is a question sent as a quiz poll
+type openPoll struct {
+	game  *chatGame
+	index int
+}
+
+// Service runs game sessions in group chats as a series of native quiz polls.
+// Poll answers are graded by the game engine like any other answers.
+type Service struct {
+	bot         *models.Bot
+	game        *game.Service
+	quizRepo    quiz.Repository
+	sessionRepo session.Repository
+	log         *slog.Logger
+
+	mu    sync.Mutex
+	games map[int64]*chatGame // by chat ID
+	polls map[string]openPoll // by poll ID
+}
+
+func NewService(
+	bot *models.Bot,
+	game *game.Service,
+	quizRepo quiz.Repository,
+	sessionRepo session.Repository,
+	log *slog.Logger,
+) *Service {
+	return &Service{
+		bot:         bot,
+		game:        game,
+		quizRepo:    quizRepo,
+		sessionRepo: sessionRepo,
+		log:         log,
+		games:       make(map[int64]*chatGame),
+		polls:       make(map[string]openPoll),
+	}
+}
+
+// Start hosts a quiz in the group chat, questions are sent one by one
+// and the leaderboard is posted at the end
+func (s *Service) Start(ctx context.Context, chatID, hostID int64, quizID string) error {
+	g := &chatGame{
+		chatID:       chatID,
+		participants: make(map[int64]string),
+	}
+
+	s.mu.Lock()
+	if _, ok := s.games[chatID]; ok {
+		s.mu.Unlock()
+		return ErrGameRunning
+	}
+	s.games[chatID] = g
+	s.mu.Unlock()
+
+	if err := s.prepare(ctx, g, quizID, hostID); err != nil {
+		s.end(g)
+		return err
+	}
+
+	go s.run(g)
+	return nil
+}
+
+// HandlePollAnswer submits a poll vote as the voter's answer,
+// voters join the game with their first answer
+func (s *Service) HandlePollAnswer(ctx context.Context, pollAnswer *tgbotapi.PollAnswer) error {
+	s.mu.Lock()
+	poll, ok := s.polls[pollAnswer.PollID]
+	s.mu.Unlock()
+
+	if !ok || len(pollAnswer.OptionIDs) == 0 {
+		return nil
+	}
+
+	participantID, err := s.participant(ctx, poll.game, &pollAnswer.User)
+	if err != nil {
+		return err
+	}
+
+	q := poll.game.questions[poll.index]
+	option := game.OptionAt(q, pollAnswer.OptionIDs[0])
+	if option == nil {
+		return game.ErrUnknownOption
+	}
+
+	_, err = s.game.SubmitAnswer(ctx, participantID, q.ID, option.ID)
+	if errors.Is(err, game.ErrQuestionClosed) || errors.Is(err, session.ErrDuplicateAnswer) {
+		// Votes arriving after the question was closed do not count
+		return nil
+	}
+
+	return err
+}
+
+// prepare creates the session of a chat game and checks its questions fit in polls
+func (s *Service) prepare(ctx context.Context, g *chatGame, quizID string, hostID int64) error {
+	q, err := s.quizRepo.Quiz(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if !q.IsPublic && q.UserID != strconv.FormatInt(hostID, 10) {
+		return ErrPrivateQuiz
+	}
+
+	// Everybody in the chat sees the same polls, nothing can be shuffled
+	gameSession, err := s.game.CreateSession(ctx, quizID, hostID, session.Settings{PoolDraw: session.DrawPerSession})
+	if err != nil {
+		return err
+	}
+	g.session = gameSession
+
+	questions, err := s.game.SessionQuestions(ctx, gameSession.ID)
+	if err != nil {
+		return err
+	}
+
+	for i, q := range questions {
+		if err := validateQuestion(q, i+1); err != nil {
+			if _, finishErr := s.game.Finish(ctx, gameSession.ID); finishErr != nil {
+				s.log.Error("Failed to finish rejected chat game", "chat_id", g.chatID, "session_id", gameSession.ID, "error", finishErr)
+			}
+			return err
+		}
+	}
+	g.questions = questions
+
+	return nil
+}
+
+// run sends the questions one by one until the last one is closed
+func (s *Service) run(g *chatGame) {
+	defer s.end(g)
+
+	ctx := context.Background()
+
+	if _, err := s.game.Start(ctx, g.session.ID); err != nil {
+		s.log.Error("Failed to start chat game", "chat_id", g.chatID, "session_id", g.session.ID, "error", err)
+		return
+	}
+
+	for index := range g.questions {
+		openPeriod, err := s.sendPoll(g, index)
+		if err != nil {
+			s.log.Error("Failed to send quiz poll", "chat_id", g.chatID, "error", err)
+		}
+
+		time.Sleep(openPeriod + closeDelay)
+
+		if _, err := s.game.NextQuestion(ctx, g.session.ID); err != nil {
+			s.log.Error("Failed to close question", "chat_id", g.chatID, "session_id", g.session.ID, "error", err)
+			return
+		}
+	}
+
+	if err := s.sendLeaderboard(ctx, g); err != nil {
+		s.log.Error("Failed to send leaderboard", "chat_id", g.chatID, "error", err)
+	}
+}
+
+// sendPoll sends the question as a quiz poll and returns how long it is open
+func (s *Service) sendPoll(g *chatGame, index int) (time.Duration, error) {
+	q := g.questions[index]
+
+	openPeriod := defaultOpenPeriod
+	if q.TimeLimit > 0 {
+		openPeriod = min(max(time.Duration(q.TimeLimit)*time.Second, minPollOpenPeriod), maxPollOpenPeriod)
+	}
+
+	text, err := markup.PlainText(q.Text)
+	if err != nil {
+		text = q.Text
+	}
+
+	options := make([]string, len(q.Options))
+	for position, option := range q.Options {
+		options[position], err = markup.PlainText(option.Text)
+		if err != nil {
+			options[position] = option.Text
+		}
+		options[position] = chattext.Truncate(options[position], maxPollOption)
+	}
+
+	// validateQuestion lets only questions with a single correct option through
+	correct := slices.IndexFunc(q.Options, func(o question.Option) bool { return o.IsCorrect })
+
+	poll := tgbotapi.NewPoll(g.chatID, chattext.Truncate(fmt.Sprintf("%d/%d. %s", index+1, len(g.questions), text), maxPollQuestion), options...)
+	poll.Type = "quiz"
+	poll.IsAnonymous = false
+	poll.CorrectOptionID = int64(correct)
+	poll.OpenPeriod = int(openPeriod / time.Second)
+
+	sent, err := s.bot.Telegram.Send(poll)
+	if err != nil {
+		return openPeriod, err
+	}
+
+	s.mu.Lock()
+	s.polls[sent.Poll.ID] = openPoll{game: g, index: index}
+	s.mu.Unlock()
+
+	return openPeriod, nil
+}
+
+// sendLeaderboard posts the best players of the game
+func (s *Service) sendLeaderboard(ctx context.Context, g *chatGame) error {
+	participants, err := s.sessionRepo.SessionParticipants(ctx, g.session.ID)
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(participants, func(a, b *session.Participant) int {
+		return b.Score - a.Score
+	})
+
+	var text strings.Builder
+	text.WriteString("🏆 <b>Итоги викторины</b>\n")
+
+	places := make([]chattext.Place, 0, len(participants))
+	for _, participant := range participants {
+		places = append(places, chattext.Place{Login: participant.Login, Score: participant.Score})
+	}
+
+	if len(places) == 0 {
+		text.WriteString("\nНикто не ответил на вопросы.\n")
+	} else {
+		text.WriteString("\n" + chattext.Leaderboard(places))
+	}
+
+	fmt.Fprintf(&text, "\nМаксимум: %d", game.MaxScore(g.questions))
+
+	msg := tgbotapi.NewMessage(g.chatID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err = s.bot.Telegram.Send(msg)
+	return err
+}
+
+// participant returns the participant of a Telegram user, joining them if needed
+func (s *Service) participant(ctx context.Context, g *chatGame, user *tgbotapi.User) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if participantID, ok := g.participants[user.ID]; ok {
+		return participantID, nil
+	}
+
+	participant, err := s.game.Join(ctx, g.session.JoinCode, nil, models.DisplayName(user))
+	if err != nil {
+		return "", err
+	}
+
+	g.participants[user.ID] = participant.ID
+	return participant.ID, nil
+}
+
+// end forgets a chat game and its polls
+func (s *Service) end(g *chatGame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.games, g.chatID)
+	for pollID, poll := range s.polls {
+		if poll.game == g {
+			delete(s.polls, pollID)
+		}
+	}
+}
+
+// validateQuestion checks that a question fits in a quiz poll
+func validateQuestion(q *question.Question, position int) error {
+	correct := 0
+	for _, option := range q.Options {
+		if option.IsCorrect {
+			correct++
+		}
+	}
+
+	switch {
+	case len(q.Options) < minPollOptions:
+		return UnsupportedQuestionError{Position: position, Reason: fmt.Sprintf("fewer than %d options", minPollOptions)}
+	case len(q.Options) > maxPollOptions:
+		return UnsupportedQuestionError{Position: position, Reason: fmt.Sprintf("more than %d options", maxPollOptions)}
+	case correct == 0:
+		return UnsupportedQuestionError{Position: position, Reason: "no correct option"}
+	case correct > 1:
+		// A quiz poll has a single correct_option_id
+		return UnsupportedQuestionError{Position: position, Reason: "more than one correct option"}
+	}
+	return nil
+}
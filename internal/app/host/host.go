@@ -0,0 +1,511 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"kahoot_bsu/internal/app/chattext"
+	"kahoot_bsu/internal/auth"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/ports"
+	"kahoot_bsu/internal/service/game"
+	"kahoot_bsu/pkg/qr"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// CallbackPrefix marks the callback data of the host buttons
+const CallbackPrefix = "host:"
+
+// Callback data kinds, "host:<kind>:<argument>"
+const (
+	callbackQuizzes = "q" // page of quizzes
+	callbackHost    = "h" // host a quiz
+	callbackControl = "c" // control a session, "c:<action>:<session ID>"
+	callbackResults = "r" // results of a session
+)
+
+// Session control actions
+const (
+	actionStart  = "start"
+	actionNext   = "next"
+	actionPause  = "pause"
+	actionFinish = "end"
+)
+
+const (
+	// quizzesPerPage is how many quizzes are listed on a page
+	quizzesPerPage = 5
+
+	// recentSessions is how many finished sessions /results offers
+	recentSessions = 5
+
+	// qrSize is the side of the join QR code in pixels
+	qrSize = 512
+
+	// maxButtonText keeps quiz titles readable on a phone
+	maxButtonText = 40
+)
+
+var (
+	ErrNotTeacher      = errors.New("only teachers can host quizzes")
+	ErrNotHost         = errors.New("the session is hosted by another user")
+	ErrPrivateQuiz     = errors.New("the quiz is private")
+	ErrInvalidCallback = errors.New("invalid host callback data")
+)
+
+// Service lets teachers list their quizzes, host and control game sessions
+// and read the results right from the bot
+type Service struct {
+	bot         *models.Bot
+	game        *game.Service
+	quizRepo    quiz.Repository
+	sessionRepo session.Repository
+	users       ports.UserRepository
+	log         *slog.Logger
+}
+
+func NewService(
+	bot *models.Bot,
+	game *game.Service,
+	quizRepo quiz.Repository,
+	sessionRepo session.Repository,
+	users ports.UserRepository,
+	log *slog.Logger,
+) *Service {
+	return &Service{
+		bot:         bot,
+		game:        game,
+		quizRepo:    quizRepo,
+		sessionRepo: sessionRepo,
+		users:       users,
+		log:         log,
+	}
+}
+
+// Quizzes sends the first page of the teacher's quizzes
+func (s *Service) Quizzes(ctx context.Context, chatID, telegramID int64) error {
+	teacher, err := s.teacher(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+
+	text, keyboard, err := s.quizzesPage(ctx, teacher, 0)
+	if err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+
+	_, err = s.bot.Telegram.Send(msg)
+	return err
+}
+
+// Host creates a session of the quiz and sends its join QR code
+// with the buttons that control the session
+func (s *Service) Host(ctx context.Context, chatID, telegramID int64, quizID string) error {
+	teacher, err := s.teacher(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+
+	q, err := s.quizRepo.Quiz(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if !q.IsPublic && q.UserID != strconv.FormatInt(teacher.ID, 10) {
+		return ErrPrivateQuiz
+	}
+
+	gameSession, err := s.game.CreateSession(ctx, q.ID, teacher.ID, session.Settings{})
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	image, err := qr.PNG(game.DeepLink(s.bot.Telegram.Self.UserName, gameSession.JoinCode), qrSize)
+	if err != nil {
+		return fmt.Errorf("failed to generate join QR code: %w", err)
+	}
+
+	caption, keyboard, err := s.panel(ctx, q, gameSession)
+	if err != nil {
+		return err
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "join.png", Bytes: image})
+	photo.Caption = caption
+	photo.ParseMode = tgbotapi.ModeHTML
+	photo.ReplyMarkup = keyboard
+
+	_, err = s.bot.Telegram.Send(photo)
+	return err
+}
+
+// Results sends the summary of a finished session, without a session ID
+// the teacher picks one of their recent sessions
+func (s *Service) Results(ctx context.Context, chatID, telegramID int64, sessionID string) error {
+	teacher, err := s.teacher(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+
+	if sessionID != "" {
+		return s.sendResults(ctx, chatID, teacher, sessionID)
+	}
+
+	sessions, err := s.sessionRepo.HostFinishedSessions(ctx, teacher.ID, recentSessions)
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		_, err := s.bot.Telegram.Send(tgbotapi.NewMessage(chatID, "У вас пока нет завершённых игр."))
+		return err
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(sessions))
+	for _, gameSession := range sessions {
+		label := "Код " + gameSession.JoinCode
+		if gameSession.EndedAt != nil {
+			label += " · " + gameSession.EndedAt.Format("02.01 15:04")
+		}
+		if q, err := s.quizRepo.Quiz(ctx, gameSession.QuizID); err == nil {
+			label = chattext.Truncate(q.Title, maxButtonText/2) + " · " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, callbackData(callbackResults, gameSession.ID)),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "📊 Выберите игру:")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	_, err = s.bot.Telegram.Send(msg)
+	return err
+}
+
+// HandleCallback handles the taps on the host buttons
+func (s *Service) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	kind, argument, _ := strings.Cut(strings.TrimPrefix(query.Data, CallbackPrefix), ":")
+
+	teacher, err := s.teacher(ctx, query.From.ID)
+	if err != nil {
+		s.notify(query, "Доступно только преподавателям")
+		return nil
+	}
+
+	if query.Message == nil {
+		return ErrInvalidCallback
+	}
+	chatID, messageID := query.Message.Chat.ID, query.Message.MessageID
+
+	switch kind {
+	case callbackQuizzes:
+		page, err := strconv.Atoi(argument)
+		if err != nil {
+			return ErrInvalidCallback
+		}
+
+		text, keyboard, err := s.quizzesPage(ctx, teacher, page)
+		if err != nil {
+			s.notify(query, "Не удалось загрузить викторины")
+			return err
+		}
+
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		edit.ParseMode = tgbotapi.ModeHTML
+		edit.ReplyMarkup = keyboard
+		if _, err := s.bot.Telegram.Send(edit); err != nil {
+			return err
+		}
+		return s.notify(query, "")
+
+	case callbackHost:
+		err := s.Host(ctx, chatID, query.From.ID, argument)
+		var quizNotFoundErr quiz.QuizNotFoundError
+		switch {
+		case errors.As(err, &quizNotFoundErr):
+			return s.notify(query, "Викторина не найдена")
+		case errors.Is(err, ErrPrivateQuiz):
+			return s.notify(query, "Это чужая закрытая викторина")
+		case err != nil:
+			s.notify(query, "Не удалось создать игру")
+			return err
+		}
+		return s.notify(query, "Игра создана")
+
+	case callbackControl:
+		action, sessionID, ok := strings.Cut(argument, ":")
+		if !ok {
+			return ErrInvalidCallback
+		}
+		return s.control(ctx, query, teacher, action, sessionID)
+
+	case callbackResults:
+		if err := s.sendResults(ctx, chatID, teacher, argument); err != nil {
+			s.notify(query, "Не удалось получить итоги")
+			return err
+		}
+		return s.notify(query, "")
+	}
+
+	return ErrInvalidCallback
+}
+
+// control applies a host action to the session and refreshes its panel
+func (s *Service) control(ctx context.Context, query *tgbotapi.CallbackQuery, teacher *models.User, action, sessionID string) error {
+	gameSession, err := s.hostedSession(ctx, teacher, sessionID)
+	if err != nil {
+		s.notify(query, "Игра не найдена")
+		return err
+	}
+
+	var act func(ctx context.Context, sessionID string) (*session.Session, error)
+	switch action {
+	case actionStart:
+		act = s.game.Start
+	case actionNext:
+		act = s.game.NextQuestion
+	case actionPause:
+		act = s.game.Pause
+	case actionFinish:
+		act = s.game.Finish
+	default:
+		return ErrInvalidCallback
+	}
+
+	gameSession, err = act(ctx, gameSession.ID)
+	switch {
+	case errors.Is(err, game.ErrSessionFinished):
+		return s.notify(query, "Игра уже окончена")
+	case errors.Is(err, game.ErrSessionStarted):
+		return s.notify(query, "Игра уже идёт")
+	case errors.Is(err, game.ErrSessionNotStarted):
+		return s.notify(query, "Игра ещё не началась")
+	case errors.Is(err, game.ErrSessionChanged):
+		// A double tap, the first one has already moved the game on
+		return s.notify(query, "")
+	case err != nil:
+		s.notify(query, "Не удалось обновить игру")
+		return err
+	}
+
+	q, err := s.quizRepo.Quiz(ctx, gameSession.QuizID)
+	if err != nil {
+		return err
+	}
+
+	caption, keyboard, err := s.panel(ctx, q, gameSession)
+	if err != nil {
+		return err
+	}
+
+	edit := tgbotapi.NewEditMessageCaption(query.Message.Chat.ID, query.Message.MessageID, caption)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.ReplyMarkup = &keyboard
+	if _, err := s.bot.Telegram.Send(edit); err != nil {
+		return err
+	}
+
+	return s.notify(query, "")
+}
+
+// sendResults sends the leaderboard and the answer statistics of a session
+func (s *Service) sendResults(ctx context.Context, chatID int64, teacher *models.User, sessionID string) error {
+	gameSession, err := s.hostedSession(ctx, teacher, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if gameSession.Status&session.StatusFinished == 0 {
+		_, err := s.bot.Telegram.Send(tgbotapi.NewMessage(chatID, "Итоги будут доступны после окончания игры."))
+		return err
+	}
+
+	report, err := s.game.Report(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	title := "Викторина"
+	if q, err := s.quizRepo.Quiz(ctx, gameSession.QuizID); err == nil {
+		title = q.Title
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "📊 <b>Итоги «%s»</b>\n", html.EscapeString(title))
+	fmt.Fprintf(&text, "Код игры: <code>%s</code>\n", html.EscapeString(gameSession.JoinCode))
+	fmt.Fprintf(&text, "Участников: %d\n", len(report.Participants))
+
+	if len(report.Participants) == 0 {
+		text.WriteString("\nНикто не присоединился к игре.")
+	} else {
+		var score, maxScore, answers, flagged int
+		for _, participant := range report.Participants {
+			score += participant.Participant.Score
+			maxScore += participant.MaxScore
+			answers += len(participant.Answers)
+			if len(participant.Flags) > 0 {
+				flagged++
+			}
+		}
+
+		if maxScore > 0 {
+			fmt.Fprintf(&text, "Средний результат: %d%%\n", score*100/maxScore)
+		}
+		fmt.Fprintf(&text, "Ответов: %d\n", answers)
+		if flagged > 0 {
+			fmt.Fprintf(&text, "⚠️ Подозрительные ответы у %d участников\n", flagged)
+		}
+
+		// Participants come ordered by score
+		places := make([]chattext.Place, 0, len(report.Participants))
+		for _, participant := range report.Participants {
+			places = append(places, chattext.Place{
+				Login:    participant.Participant.Login,
+				Score:    participant.Participant.Score,
+				MaxScore: participant.MaxScore,
+			})
+		}
+		text.WriteString("\n" + chattext.Leaderboard(places))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err = s.bot.Telegram.Send(msg)
+	return err
+}
+
+// quizzesPage renders a page of the teacher's quizzes, each quiz is a button that hosts it
+func (s *Service) quizzesPage(ctx context.Context, teacher *models.User, page int) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	quizzes, err := s.quizRepo.UserQuizzes(ctx, teacher.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(quizzes) == 0 {
+		return "У вас пока нет викторин. Создайте их в приложении.", nil, nil
+	}
+
+	pages := (len(quizzes) + quizzesPerPage - 1) / quizzesPerPage
+	page = min(max(page, 0), pages-1)
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, quizzesPerPage+1)
+	for _, q := range quizzes[page*quizzesPerPage : min((page+1)*quizzesPerPage, len(quizzes))] {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("▶️ "+chattext.Truncate(q.Title, maxButtonText), callbackData(callbackHost, q.ID)),
+		))
+	}
+
+	if pages > 1 {
+		var navigation []tgbotapi.InlineKeyboardButton
+		if page > 0 {
+			navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("◀️", callbackData(callbackQuizzes, strconv.Itoa(page-1))))
+		}
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d/%d", page+1, pages), callbackData(callbackQuizzes, strconv.Itoa(page))))
+		if page < pages-1 {
+			navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("▶️", callbackData(callbackQuizzes, strconv.Itoa(page+1))))
+		}
+		rows = append(rows, navigation)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	text := fmt.Sprintf("📚 <b>Ваши викторины</b> (%d)\nВыберите викторину, чтобы начать игру.", len(quizzes))
+	return text, &keyboard, nil
+}
+
+// panel renders the state of a hosted session and the buttons allowed in it
+func (s *Service) panel(ctx context.Context, q *quiz.Quiz, gameSession *session.Session) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	questions, err := s.game.SessionQuestions(ctx, gameSession.ID)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	participants, err := s.sessionRepo.SessionParticipants(ctx, gameSession.ID)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "🎯 <b>%s</b>\n", html.EscapeString(q.Title))
+	fmt.Fprintf(&text, "Код для входа: <code>%s</code>\n", html.EscapeString(gameSession.JoinCode))
+	fmt.Fprintf(&text, "Участников: %d\n", len(participants))
+
+	control := func(label, action string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(label, callbackData(callbackControl, action+":"+gameSession.ID))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	switch {
+	case gameSession.Status&session.StatusFinished != 0:
+		text.WriteString("\n🏁 Игра окончена")
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📊 Итоги", callbackData(callbackResults, gameSession.ID)),
+		))
+	case gameSession.Status&session.StatusWaiting != 0:
+		text.WriteString("\n⏳ Ждём участников. Отсканируйте QR-код, чтобы присоединиться.")
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(control("▶️ Начать", actionStart), control("⏹ Завершить", actionFinish)))
+	case gameSession.Status&session.StatusPaused != 0:
+		fmt.Fprintf(&text, "\n⏸ Пауза на вопросе %d/%d", gameSession.CurrentQuestionIndex+1, len(questions))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(control("⏭ Следующий", actionNext), control("⏹ Завершить", actionFinish)))
+	default:
+		fmt.Fprintf(&text, "\n❓ Вопрос %d/%d", gameSession.CurrentQuestionIndex+1, len(questions))
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(control("⏭ Следующий", actionNext), control("⏸ Пауза", actionPause)),
+			tgbotapi.NewInlineKeyboardRow(control("⏹ Завершить", actionFinish)),
+		)
+	}
+
+	return text.String(), tgbotapi.NewInlineKeyboardMarkup(rows...), nil
+}
+
+// teacher returns the registered teacher with the Telegram ID
+func (s *Service) teacher(ctx context.Context, telegramID int64) (*models.User, error) {
+	user, err := s.users.UserByTelegramID(ctx, telegramID)
+	if err != nil {
+		var userNotFoundErr ports.UserNotFoundError
+		if errors.As(err, &userNotFoundErr) {
+			return nil, ErrNotTeacher
+		}
+		return nil, err
+	}
+
+	if !auth.New(user).IsTeacher() {
+		return nil, ErrNotTeacher
+	}
+
+	return user, nil
+}
+
+// hostedSession returns the session if the teacher hosts it
+func (s *Service) hostedSession(ctx context.Context, teacher *models.User, sessionID string) (*session.Session, error) {
+	gameSession, err := s.sessionRepo.Session(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if gameSession.HostID != teacher.ID {
+		return nil, ErrNotHost
+	}
+
+	return gameSession, nil
+}
+
+// notify answers the callback query with a short toast
+func (s *Service) notify(query *tgbotapi.CallbackQuery, text string) error {
+	_, err := s.bot.Telegram.Request(tgbotapi.NewCallback(query.ID, text))
+	return err
+}
+
+func callbackData(kind, argument string) string {
+	return CallbackPrefix + kind + ":" + argument
+}
@@ -0,0 +1,463 @@
+package play
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"kahoot_bsu/internal/app/chattext"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/ports"
+	"kahoot_bsu/internal/service/game"
+	"kahoot_bsu/internal/service/markup"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// CallbackPrefix marks the callback data of answer buttons
+const CallbackPrefix = "play:"
+
+const (
+	// tickInterval is how often the countdowns of the open questions are checked
+	tickInterval = time.Second
+
+	// resyncInterval is how often the bot reads the sessions of its players
+	// to catch up with the changes it missed, the game events bring them
+	// from the web app at once
+	resyncInterval = 15 * time.Second
+
+	// requestTimeout bounds the requests of a session change
+	requestTimeout = 5 * time.Second
+
+	// countdownStep is how often the countdown of a question is updated,
+	// Telegram limits how many messages a bot may edit per second
+	countdownStep = 10 * time.Second
+
+	// buttonLabelLength is the longest option text shown on a button
+	buttonLabelLength = 40
+)
+
+var (
+	ErrInvalidCallback = errors.New("invalid answer callback data")
+)
+
+// player is a participant playing in a Telegram chat
+type player struct {
+	mu sync.Mutex
+
+	chatID      int64
+	userID      int64
+	participant *session.Participant
+	questions   []*question.Question
+
+	// The question message shown now, messageID is 0 when there is none
+	index     int
+	messageID int
+	text      string
+	options   []string
+	keyboard  tgbotapi.InlineKeyboardMarkup
+	deadline  time.Time
+	shownLeft time.Duration
+}
+
+// Service plays game sessions inside Telegram chats: questions arrive as
+// messages with an inline keyboard of options, taps submit the answers
+type Service struct {
+	bot         *models.Bot
+	game        *game.Service
+	sessionRepo session.Repository
+	renderer    ports.FormulaRenderer
+	log         *slog.Logger
+
+	mu       sync.Mutex
+	players  map[string]*player   // by participant ID
+	sessions map[string][]*player // by session ID
+}
+
+func NewService(
+	bot *models.Bot,
+	game *game.Service,
+	sessionRepo session.Repository,
+	renderer ports.FormulaRenderer,
+	log *slog.Logger,
+) *Service {
+	return &Service{
+		bot:         bot,
+		game:        game,
+		sessionRepo: sessionRepo,
+		renderer:    renderer,
+		log:         log,
+		players:     make(map[string]*player),
+		sessions:    make(map[string][]*player),
+	}
+}
+
+// Join registers a Telegram user in the session with the join code
+// and starts sending them its questions
+func (s *Service) Join(ctx context.Context, chatID, userID int64, login, joinCode string) (*session.Participant, error) {
+	participant, err := s.game.JoinTelegram(ctx, strings.ToUpper(joinCode), userID, nil, login)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.game.ParticipantQuestions(ctx, participant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &player{
+		chatID:      chatID,
+		userID:      userID,
+		participant: participant,
+		questions:   questions,
+		index:       -1,
+	}
+
+	s.mu.Lock()
+	if _, ok := s.players[participant.ID]; ok {
+		// A user who sends /play again keeps playing as the same player
+		s.mu.Unlock()
+		return participant, nil
+	}
+	watching := len(s.sessions[participant.SessionID]) > 0
+	s.players[participant.ID] = p
+	s.sessions[participant.SessionID] = append(s.sessions[participant.SessionID], p)
+	s.mu.Unlock()
+
+	if !watching {
+		go s.watch(participant.SessionID)
+	}
+
+	return participant, nil
+}
+
+// HandleCallback submits the answer of a tapped option button
+func (s *Service) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	participantID, index, position, err := parseCallbackData(query.Data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	p := s.players[participantID]
+	s.mu.Unlock()
+
+	switch {
+	case p == nil:
+		return s.notify(query, "Игра уже окончена")
+	case p.userID != query.From.ID:
+		return s.notify(query, "Это вопрос другого игрока")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.messageID == 0 || p.index != index {
+		return s.notify(query, "Вопрос уже закрыт")
+	}
+
+	q := p.questions[index]
+	option := game.OptionAt(q, position)
+	if option == nil {
+		return s.notify(query, "Неизвестный вариант ответа")
+	}
+
+	answer, err := s.game.SubmitAnswer(ctx, p.participant.ID, q.ID, option.ID)
+	switch {
+	case errors.Is(err, game.ErrQuestionClosed):
+		return s.notify(query, "Время вышло")
+	case errors.Is(err, session.ErrDuplicateAnswer):
+		return s.notify(query, "Вы уже ответили")
+	case err != nil:
+		s.notify(query, "Не удалось принять ответ")
+		return fmt.Errorf("failed to submit answer: %w", err)
+	}
+
+	result := "❌ Неверно"
+	if answer.IsCorrect {
+		result = fmt.Sprintf("✅ Верно! +%d", answer.PointsAwarded)
+	}
+
+	s.closeQuestion(p, fmt.Sprintf("Ваш ответ: %d. %s\n%s", position+1, p.options[position], result))
+	return s.notify(query, "Ответ принят")
+}
+
+// watch follows a session until it is finished
+func (s *Service) watch(sessionID string) {
+	events, unsubscribe := s.game.Subscribe(sessionID)
+	defer unsubscribe()
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	resync := time.NewTicker(resyncInterval)
+	defer resync.Stop()
+
+	var last session.Session
+
+	// The session may have changed before the subscription
+	if s.resync(sessionID, &last) {
+		return
+	}
+
+	for {
+		select {
+		case gameSession, ok := <-events:
+			if !ok || s.apply(sessionID, &last, &gameSession) {
+				return
+			}
+		case <-resync.C:
+			if s.resync(sessionID, &last) {
+				return
+			}
+		case <-ticker.C:
+			s.mu.Lock()
+			players := s.sessions[sessionID]
+			s.mu.Unlock()
+
+			for _, p := range players {
+				s.countdown(p)
+			}
+		}
+	}
+}
+
+// resync reads the session from the database and applies its changes,
+// it reports whether the session is finished
+func (s *Service) resync(sessionID string, last *session.Session) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	gameSession, err := s.sessionRepo.Session(ctx, sessionID)
+	if err != nil {
+		s.log.Error("Failed to fetch session", "session_id", sessionID, "error", err)
+		return false
+	}
+
+	return s.apply(sessionID, last, gameSession)
+}
+
+// apply shows the players a session state that differs from the last one,
+// it reports whether the session is finished
+func (s *Service) apply(sessionID string, last *session.Session, gameSession *session.Session) bool {
+	changed := last.Status != gameSession.Status ||
+		last.CurrentQuestionIndex != gameSession.CurrentQuestionIndex ||
+		!sameTime(last.QuestionOpenedAt, gameSession.QuestionOpenedAt)
+	*last = *gameSession
+
+	if changed {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+
+		s.mu.Lock()
+		players := s.sessions[sessionID]
+		s.mu.Unlock()
+
+		for _, p := range players {
+			s.update(ctx, p, gameSession)
+		}
+	}
+
+	if gameSession.Status&session.StatusFinished != 0 {
+		s.forget(sessionID)
+		return true
+	}
+	return false
+}
+
+// update shows the player what the new session state means for them
+func (s *Service) update(ctx context.Context, p *player, gameSession *session.Session) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	index := gameSession.CurrentQuestionIndex
+
+	switch {
+	case gameSession.Status&session.StatusFinished != 0:
+		s.closeQuestion(p, "⌛ Время вышло")
+		s.sendResult(ctx, p)
+	case gameSession.Status&session.StatusPaused != 0:
+		s.closeQuestion(p, "⏸ Игра приостановлена")
+	case gameSession.Status&session.StatusActive != 0 && index != p.index:
+		s.closeQuestion(p, "⌛ Время вышло")
+		if index < len(p.questions) && gameSession.QuestionOpenedAt != nil {
+			s.sendQuestion(ctx, p, index, *gameSession.QuestionOpenedAt)
+		}
+	}
+}
+
+// sendQuestion sends the question with its option buttons
+func (s *Service) sendQuestion(ctx context.Context, p *player, index int, openedAt time.Time) {
+	q := p.questions[index]
+
+	text, err := markup.RenderTelegramHTML(q.Text, s.renderer)
+	if err != nil {
+		s.log.Error("Failed to render question", "question_id", q.ID, "error", err)
+		text = html.EscapeString(q.Text)
+	}
+
+	var (
+		body    strings.Builder
+		options = make([]string, len(q.Options))
+		rows    = make([][]tgbotapi.InlineKeyboardButton, len(q.Options))
+	)
+
+	fmt.Fprintf(&body, "<b>Вопрос %d/%d</b>", index+1, len(p.questions))
+	if q.IsBonus {
+		body.WriteString(" · бонусный")
+	}
+	body.WriteString("\n\n" + text + "\n")
+
+	for position, option := range q.Options {
+		options[position], err = markup.RenderTelegramHTML(option.Text, s.renderer)
+		if err != nil {
+			options[position] = html.EscapeString(option.Text)
+		}
+		fmt.Fprintf(&body, "\n%d. %s", position+1, options[position])
+
+		label, err := markup.PlainText(option.Text)
+		if err != nil {
+			label = option.Text
+		}
+		data := fmt.Sprintf("%s%s:%d:%d", CallbackPrefix, p.participant.ID, index, position)
+		rows[position] = tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d. %s", position+1, chattext.Truncate(label, buttonLabelLength)), data),
+		)
+	}
+
+	p.index = index
+	p.text = body.String()
+	p.options = options
+	p.keyboard = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	p.deadline = time.Time{}
+	if q.TimeLimit > 0 {
+		p.deadline = openedAt.Add(time.Duration(q.TimeLimit) * time.Second)
+	}
+	p.shownLeft = time.Until(p.deadline)
+
+	msg := tgbotapi.NewMessage(p.chatID, p.text+"\n\n"+countdownText(p.deadline))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = p.keyboard
+
+	sent, err := s.bot.Telegram.Send(msg)
+	if err != nil {
+		s.log.Error("Failed to send question", "chat_id", p.chatID, "error", err)
+		return
+	}
+	p.messageID = sent.MessageID
+}
+
+// countdown updates the time left on the open question and closes it
+// when the time is up
+func (s *Service) countdown(p *player) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.messageID == 0 || p.deadline.IsZero() {
+		return
+	}
+
+	left := time.Until(p.deadline)
+	if left <= 0 {
+		s.closeQuestion(p, "⌛ Время вышло")
+		return
+	}
+
+	if p.shownLeft-left < countdownStep {
+		return
+	}
+	p.shownLeft = left
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(p.chatID, p.messageID, p.text+"\n\n"+countdownText(p.deadline), p.keyboard)
+	edit.ParseMode = tgbotapi.ModeHTML
+	if _, err := s.bot.Telegram.Send(edit); err != nil {
+		s.log.Error("Failed to update countdown", "chat_id", p.chatID, "error", err)
+	}
+}
+
+// closeQuestion replaces the buttons of the open question with footer,
+// must be called with p.mu held
+func (s *Service) closeQuestion(p *player, footer string) {
+	if p.messageID == 0 {
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(p.chatID, p.messageID, p.text+"\n\n"+footer)
+	edit.ParseMode = tgbotapi.ModeHTML
+	if _, err := s.bot.Telegram.Send(edit); err != nil {
+		s.log.Error("Failed to close question", "chat_id", p.chatID, "error", err)
+	}
+
+	p.messageID = 0
+}
+
+// sendResult tells the player their final score
+func (s *Service) sendResult(ctx context.Context, p *player) {
+	participant, err := s.sessionRepo.Participant(ctx, p.participant.ID)
+	if err != nil {
+		s.log.Error("Failed to fetch participant", "participant_id", p.participant.ID, "error", err)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(p.chatID, fmt.Sprintf("🏁 Игра окончена! Ваш результат: %d", participant.Score))
+	if _, err := s.bot.Telegram.Send(msg); err != nil {
+		s.log.Error("Failed to send result", "chat_id", p.chatID, "error", err)
+	}
+}
+
+// forget drops the players of a finished session
+func (s *Service) forget(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.sessions[sessionID] {
+		delete(s.players, p.participant.ID)
+	}
+	delete(s.sessions, sessionID)
+}
+
+// notify answers the callback query with a short toast
+func (s *Service) notify(query *tgbotapi.CallbackQuery, text string) error {
+	_, err := s.bot.Telegram.Request(tgbotapi.NewCallback(query.ID, text))
+	return err
+}
+
+// parseCallbackData parses "play:<participant ID>:<question index>:<option position>"
+func parseCallbackData(data string) (string, int, int, error) {
+	parts := strings.Split(strings.TrimPrefix(data, CallbackPrefix), ":")
+	if len(parts) != 3 {
+		return "", 0, 0, ErrInvalidCallback
+	}
+
+	index, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, 0, ErrInvalidCallback
+	}
+
+	position, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", 0, 0, ErrInvalidCallback
+	}
+
+	return parts[0], index, position, nil
+}
+
+func countdownText(deadline time.Time) string {
+	if deadline.IsZero() {
+		return "Выберите ответ"
+	}
+	return fmt.Sprintf("⏳ Осталось %d с", int(time.Until(deadline).Round(time.Second).Seconds()))
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -2,14 +2,28 @@ package telegram
 
 import (
 	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"kahoot_bsu/internal/app/command"
+	"kahoot_bsu/internal/app/editor"
+	"kahoot_bsu/internal/app/group"
+	"kahoot_bsu/internal/app/host"
+	"kahoot_bsu/internal/app/play"
 	"kahoot_bsu/internal/config"
 	"kahoot_bsu/internal/domain/models"
 	"kahoot_bsu/internal/infra/clients"
 	infra "kahoot_bsu/internal/infra/persistence"
 	"kahoot_bsu/internal/infra/services"
+	messages "kahoot_bsu/internal/interfaces/http/handlers/telegram"
+	"kahoot_bsu/internal/service/game"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"kahoot_bsu/internal/logger/handlers/slogpretty"
+	"kahoot_bsu/internal/ports"
 	"kahoot_bsu/internal/service/fsm"
 	"log/slog"
 	"os"
@@ -29,7 +43,21 @@ type AppTelegram struct {
 	Conn   *pgxpool.Pool
 	Bot    *models.Bot
 	Log    *slog.Logger
-	router *fsm.Router
+	// Webhook receives the updates in webhook mode, nil in polling mode
+	Webhook *messages.Webhook
+	router  *fsm.Router
+	users   ports.UserRepository
+	game    *game.Service
+	play    *play.Service
+	group   *group.Service
+	host    *host.Service
+	editor  *editor.Service
+	// timeouts sends the reminders and resets the abandoned states
+	timeouts *fsm.Timeouts
+	// events share the session changes with the API server
+	events *infra.RedisEvents
+	// registerScene is entered by /register
+	registerScene *fsm.Scene
 }
 
 func NewAppTelegram() (
@@ -40,8 +68,8 @@ func NewAppTelegram() (
 	cfg := config.MustLoad()
 	log := setupLogger(cfg.Env)
 
-	telegramBot := newBot(cfg.BotConfig)
-	
+	telegramBot, webhook := newBot(cfg.BotConfig)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -53,38 +81,113 @@ func NewAppTelegram() (
 
 	redisStorage := NewRedisStorage(ctx, cfg.RedisConfig)
 
-	router := fsm.NewRouter(redisStorage)
+	timeouts := fsm.NewTimeouts(
+		newFSMStorage(cfg.FSMConfig, db, redisStorage),
+		infra.NewRedisScheduler(redisStorage, cfg.FSMConfig.PollInterval, log),
+		telegramBot,
+		log,
+	)
+	timeouts.Declare(fsm.StateAwaitingLogin, fsm.Timeout{
+		After:       cfg.FSMConfig.LoginTimeout,
+		Expired:     "Время регистрации истекло. Чтобы начать заново, отправьте /register",
+		RemindAfter: cfg.FSMConfig.LoginReminder,
+		Reminder:    "Вы не закончили регистрацию. Введите ваш логин",
+	})
+	timeouts.Declare(fsm.StateAwaitingOTP, fsm.Timeout{
+		After:       cfg.FSMConfig.OTPTimeout,
+		Expired:     "Код подтверждения истёк. Чтобы получить новый, отправьте /register",
+		RemindAfter: cfg.FSMConfig.OTPReminder,
+		Reminder:    "Введите код подтверждения, отправленный на вашу почту",
+	})
+
+	router := fsm.NewRouter(timeouts)
 
 	emailClient := clients.NewEmailClient(cfg.EmailConfig)
 	emailService := services.NewEmailService(emailClient)
 	userRepo := infra.NewPgUserRepository(db)
 	otpGenerator := services.NewVerificationOTPGenerator(6)
 
-	fSMHandler := messages.NewFSMHandler(emailService, userRepo, otpGenerator)
+	codeRepo := infra.NewPgVerificationCodeRepository(db)
+
+	fSMHandler := messages.NewFSMHandler(emailService, userRepo, codeRepo, otpGenerator)
 
-	router.Register(fsm.StateAwaitingLogin, fSMHandler.HandleLogin)
-	router.Register(fsm.StateAwaitingOTP, fSMHandler.HandleOTP)
+	router.Use(
+		fsm.Recovery(log),
+		fsm.Logging(log),
+		fsm.Metrics(expvar.NewMap("fsm")),
+		fsm.RejectBlocked(userRepo),
+		// At most one message per second with bursts of 5 per user
+		fsm.Throttle(1, 5),
+	)
+
+	registerScene := fSMHandler.RegisterScene()
 	router.Register(fsm.StateRegistered, fSMHandler.HandleRegistered)
 
+	sessionRepo := infra.NewPgSessionRepository(db)
+	events := infra.NewRedisEvents(redisStorage, log)
+	gameService := game.NewService(
+		sessionRepo,
+		infra.NewPgQuestionRepository(db),
+		infra.NewPgPoolRepository(db),
+		services.NewJoinCodeGenerator(6),
+		game.WithEvents(events),
+		game.WithTransactor(infra.NewPgTransactor(db)),
+	)
+	playService := play.NewService(telegramBot, gameService, sessionRepo, clients.NewFormulaClient(cfg.FormulaConfig), log)
+	quizRepo := infra.NewPgQuizRepository(db)
+	groupService := group.NewService(telegramBot, gameService, quizRepo, sessionRepo, log)
+	hostService := host.NewService(telegramBot, gameService, quizRepo, sessionRepo, userRepo, log)
+	editorService := editor.NewService(quizRepo, infra.NewPgQuestionRepository(db), userRepo, infra.NewPgTransactor(db), log)
+
+	DeclareTransitions(router, registerScene, editorService)
+	if err := router.Validate(); err != nil {
+		panic(err)
+	}
+
 	app = &AppTelegram{
-		Config: cfg,
-		Bot:    telegramBot,
-		Conn:   db,
-		Log:    log,
-		router: router,
+		Config:   cfg,
+		Bot:      telegramBot,
+		Conn:     db,
+		Log:      log,
+		Webhook:  webhook,
+		router:   router,
+		users:    userRepo,
+		game:     gameService,
+		play:     playService,
+		group:    groupService,
+		host:     hostService,
+		editor:   editorService,
+		timeouts: timeouts,
+		events:   events,
+
+		registerScene: registerScene,
+	}
+
+	var webhookServer *http.Server
+	if webhook != nil {
+		webhookServer = serveWebhook(cfg.BotConfig.Webhook, webhook, log)
 	}
 
 	closeFunc := func() error {
 		var err error
 
-		// if i will have errors I should use it
+		if webhookServer != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			err = webhookServer.Shutdown(ctx)
+			webhook.Close()
+		}
+
 		return err
 	}
 
 	return app, closeFunc
 }
 
-func newBot(cfg config.BotConfig) *models.Bot {
+// newBot connects to the bot API and receives updates by long polling or,
+// in webhook mode, through the returned webhook
+func newBot(cfg config.BotConfig) (*models.Bot, *messages.Webhook) {
 	botAPI, err := tgbotapi.NewBotAPI(cfg.Token)
 	if err != nil {
 		panic(err)
@@ -92,15 +195,104 @@ func newBot(cfg config.BotConfig) *models.Bot {
 
 	botAPI.Debug = cfg.Debug
 
-	// Note: add With and functional option pattern
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = cfg.Timeout
+	switch cfg.Mode {
+	case config.BotModePolling:
+		// Telegram refuses getUpdates while a webhook is set
+		if _, err := botAPI.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			panic(err)
+		}
+
+		// Note: add With and functional option pattern
+		u := tgbotapi.NewUpdate(0)
+		u.Timeout = cfg.Timeout
+
+		return &models.Bot{
+			Telegram:      botAPI,
+			UpdateChannel: botAPI.GetUpdatesChan(u),
+		}, nil
+
+	case config.BotModeWebhook:
+		if cfg.Webhook.SecretToken == "" {
+			panic("webhook secret token is required in webhook mode")
+		}
+		// Nothing else serves the webhook, without an address no update arrives
+		if cfg.Webhook.Listen == "" {
+			panic("webhook listen address is required in webhook mode")
+		}
+
+		webhook := messages.NewWebhook(cfg.Webhook.SecretToken, botAPI.Buffer)
+		if err := messages.SetWebhook(botAPI, cfg.Webhook); err != nil {
+			panic(err)
+		}
+
+		return &models.Bot{
+			Telegram:      botAPI,
+			UpdateChannel: webhook.Updates(),
+		}, webhook
+
+	default:
+		panic("unknown bot mode: " + cfg.Mode)
+	}
+}
+
+// serveWebhook serves the webhook on its own address at the path of the webhook URL
+func serveWebhook(cfg config.WebhookConfig, webhook *messages.Webhook, log *slog.Logger) *http.Server {
+	path := "/"
+	if u, err := url.Parse(cfg.URL); err == nil && u.Path != "" {
+		path = u.Path
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, webhook)
+
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Info("Webhook server listening", "addr", cfg.Listen, "path", path)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error("Webhook server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	return srv
+}
+
+// DeclareTransitions registers the scenes and declares the conversation graph of the bot,
+// run cmd/fsmgraph to draw it
+func DeclareTransitions(router *fsm.Router, registerScene *fsm.Scene, editorService *editor.Service) {
+	registerScene.Register(router)
+	editorService.Register(router)
+	router.Transition(fsm.StateAwaitingLogin, fsm.DefaultState, "timeout")
+	router.Transition(fsm.StateAwaitingOTP, fsm.DefaultState, "timeout")
+}
 
-	updates := botAPI.GetUpdatesChan(u)
+// NewGraphRouter declares the conversation graph without the services behind
+// the handlers, the handlers must not be run
+func NewGraphRouter() *fsm.Router {
+	router := fsm.NewRouter(nil)
+	DeclareTransitions(router,
+		messages.NewFSMHandler(nil, nil, nil, nil).RegisterScene(),
+		editor.NewService(nil, nil, nil, nil, nil),
+	)
+	return router
+}
 
-	return &models.Bot{
-		Telegram:      botAPI,
-		UpdateChannel: updates,
+// newFSMStorage returns the storage of the FSM state chosen in the config
+func newFSMStorage(cfg config.FSMConfig, db *pgxpool.Pool, redisStorage *infra.RedisStorage) fsm.Storage {
+	switch cfg.Storage {
+	case config.FSMStorageMemory:
+		return infra.NewMemoryStorage(cfg.LoginTimeout + cfg.OTPTimeout)
+	case config.FSMStorageRedis:
+		return redisStorage
+	case config.FSMStoragePostgres:
+		return infra.NewPgStorage(db)
+	default:
+		panic("unknown fsm storage: " + cfg.Storage)
 	}
 }
 
@@ -128,28 +320,128 @@ func NewRedisStorage(ctx context.Context, cfg config.RedisConfig) *infra.RedisSt
 	return storage
 }
 
+// Start dispatches the updates to the workers until the update channel is closed
 func Start(ctx context.Context, a *AppTelegram) {
-	for update := range a.Bot.UpdateChannel {
-		if update.Message == nil {
-			continue
+	cfg := a.Config.BotConfig
+	d := newDispatcher(
+		func(ctx context.Context, update tgbotapi.Update) error { return handleUpdate(ctx, a, update) },
+		cfg.Workers,
+		cfg.QueueSize,
+		cfg.UpdateTimeout,
+		a.Log,
+	)
+	defer d.close()
+
+	go a.timeouts.Run(ctx)
+	go a.events.Run(ctx)
+
+	for {
+		select {
+		case update, ok := <-a.Bot.UpdateChannel:
+			if !ok {
+				return
+			}
+			d.dispatch(update)
+		case <-ctx.Done():
+			return
 		}
+	}
+}
 
-		chatID := update.Message.Chat.ID
-		userID := update.Message.From.ID
-		message := update.Message
-		states := 
-	
-		fsm := fsm.NewFSMContext(ctx, a.router.Storage, chatID, userID)
-
-		if update.Message.IsCommand() {
-			// handleCommand(a, message, fsm)
-		} else {
-			if err := a.router.ProcessUpdate(ctx, message, a.Bot, fsm); err != nil {
-				a.Log.Error("Error processing update: %v", err)
-			}
+// handleUpdate routes an update to its handler. Game buttons have their own routes,
+// the rest goes to the handlers of the user's FSM state first, so a flow can
+// handle its own commands, and then to the global commands.
+// Every route runs through the middlewares of the router.
+func handleUpdate(ctx context.Context, a *AppTelegram, update tgbotapi.Update) error {
+	if update.CallbackQuery != nil && isGameCallback(update.CallbackQuery.Data) {
+		return a.router.Run(ctx, &update, a.Bot, func(ctx context.Context, _ *fsm.FSMContext, update *tgbotapi.Update, _ *models.Bot) error {
+			return handleCallback(ctx, a, update.CallbackQuery)
+		})
+	}
+
+	err := a.router.Dispatch(ctx, &update, a.Bot)
+	if errors.Is(err, fsm.ErrStateChanged) {
+		// Another update of the user has already moved the conversation on
+		a.Log.Debug("Dropped update of a changed state", "update_id", update.UpdateID)
+		return nil
+	}
+	if !errors.Is(err, fsm.ErrNoHandler) {
+		if err != nil {
+			return fmt.Errorf("failed to process update: %w", err)
+		}
+		return nil
+	}
+
+	switch {
+	case update.Message != nil && update.Message.IsCommand():
+		return a.router.Run(ctx, &update, a.Bot, func(ctx context.Context, fsmCtx *fsm.FSMContext, update *tgbotapi.Update, _ *models.Bot) error {
+			handleCommand(ctx, a, update.Message, fsmCtx)
+			return nil
+		})
+	case update.PollAnswer != nil:
+		// Votes in the polls of group games
+		err := a.router.Run(ctx, &update, a.Bot, func(ctx context.Context, _ *fsm.FSMContext, update *tgbotapi.Update, _ *models.Bot) error {
+			return a.group.HandlePollAnswer(ctx, update.PollAnswer)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to handle poll answer: %w", err)
+		}
+	case update.Message != nil:
+		return fmt.Errorf("failed to process message: %w", err)
+	}
+
+	return nil
+}
+
+type CommandInterface interface {
+	Execute(ctx context.Context, message *tgbotapi.Message)
+}
+
+func handleCommand(ctx context.Context, a *AppTelegram, message *tgbotapi.Message, fsm *fsm.FSMContext) {
+	comandHandler := command.New(a.Bot, a.Config.WebAppConfig.URL, a.Log)
+	joinHandler := command.NewJoinCommand(comandHandler, a.game, a.users)
+
+	commandStrategy := map[string]CommandInterface{
+		"start":     &command.StartCommand{CommandHandler: comandHandler, Join: joinHandler},
+		"join":      joinHandler,
+		"register":  command.NewRegisterCommand(comandHandler, fsm, a.registerScene),
+		"kahoot":    &command.KahootComand{CommandHandler: comandHandler},
+		"play":      command.NewPlayCommand(comandHandler, a.play),
+		"groupquiz": command.NewGroupQuizCommand(comandHandler, a.group, a.users),
+		"myquizzes": command.NewMyQuizzesCommand(comandHandler, a.host),
+		"host":      command.NewHostCommand(comandHandler, a.host),
+		"results":   command.NewResultsCommand(comandHandler, a.host),
+		"newquiz":   command.NewNewQuizCommand(comandHandler, a.editor, fsm),
+		"editquiz":  command.NewEditQuizCommand(comandHandler, a.editor, fsm),
+		"help":      &command.HelpCommand{CommandHandler: comandHandler},
+		"unknown":   &command.UnknownCommand{CommandHandler: comandHandler},
+	}
 
+	handler, ok := commandStrategy[message.Command()]
+	if !ok {
+		handler = commandStrategy["unknown"]
+	}
+
+	handler.Execute(ctx, message)
+}
+
+// isGameCallback reports whether the button belongs to a game
+func isGameCallback(data string) bool {
+	return strings.HasPrefix(data, play.CallbackPrefix) || strings.HasPrefix(data, host.CallbackPrefix)
+}
+
+func handleCallback(ctx context.Context, a *AppTelegram, query *tgbotapi.CallbackQuery) error {
+	switch {
+	case strings.HasPrefix(query.Data, play.CallbackPrefix):
+		if err := a.play.HandleCallback(ctx, query); err != nil {
+			return fmt.Errorf("failed to handle answer: %w", err)
+		}
+	case strings.HasPrefix(query.Data, host.CallbackPrefix):
+		if err := a.host.HandleCallback(ctx, query); err != nil {
+			return fmt.Errorf("failed to handle host action: %w", err)
 		}
 	}
+	return nil
 }
 
 func setupLogger(env string) *slog.Logger {
@@ -0,0 +1,109 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleFunc processes a single update
+type handleFunc func(ctx context.Context, update tgbotapi.Update) error
+
+// dispatcher processes updates on a fixed number of workers. Updates of one chat
+// always go to the same worker, so they are handled in the order they came,
+// while a slow update of one chat does not stall the other chats.
+type dispatcher struct {
+	handle  handleFunc
+	timeout time.Duration
+	log     *slog.Logger
+
+	shards []chan tgbotapi.Update
+	wg     sync.WaitGroup
+}
+
+// newDispatcher starts the workers, each buffers up to queueSize updates
+func newDispatcher(handle handleFunc, workers, queueSize int, timeout time.Duration, log *slog.Logger) *dispatcher {
+	d := &dispatcher{
+		handle:  handle,
+		timeout: timeout,
+		log:     log,
+		shards:  make([]chan tgbotapi.Update, max(workers, 1)),
+	}
+
+	for i := range d.shards {
+		d.shards[i] = make(chan tgbotapi.Update, queueSize)
+
+		d.wg.Add(1)
+		go d.work(d.shards[i])
+	}
+
+	return d
+}
+
+// dispatch queues the update on the worker of its chat,
+// it blocks while the queue of the worker is full
+func (d *dispatcher) dispatch(update tgbotapi.Update) {
+	shard := uint64(updateChatID(update)) % uint64(len(d.shards))
+	d.shards[shard] <- update
+}
+
+// close waits for the queued updates to be handled
+func (d *dispatcher) close() {
+	for _, shard := range d.shards {
+		close(shard)
+	}
+	d.wg.Wait()
+}
+
+func (d *dispatcher) work(updates <-chan tgbotapi.Update) {
+	defer d.wg.Done()
+
+	for update := range updates {
+		d.process(update)
+	}
+}
+
+// process handles one update within the timeout, recovering from a panic in the handler
+func (d *dispatcher) process(update tgbotapi.Update) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	started := time.Now()
+	log := d.log.With("update_id", update.UpdateID, "chat_id", updateChatID(update))
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("Panic while handling update", "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+		}
+	}()
+
+	err := d.handle(ctx, update)
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		log.Warn("Update handling timed out", "timeout", d.timeout, "error", err)
+	case err != nil:
+		log.Error("Failed to handle update", "duration", time.Since(started), "error", err)
+	}
+}
+
+// updateChatID is the chat an update belongs to,
+// poll answers have no chat and are ordered per voter
+func updateChatID(update tgbotapi.Update) int64 {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	if update.PollAnswer != nil {
+		return update.PollAnswer.User.ID
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInitDataMissing   = errors.New("telegram init data is missing")
+	ErrInitDataSignature = errors.New("telegram init data signature is invalid")
+	ErrInitDataExpired   = errors.New("telegram init data is expired")
+	ErrInitDataMalformed = errors.New("telegram init data is malformed")
+)
+
+// WebAppUser is the Telegram user who opened the Mini App
+type WebAppUser struct {
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
+}
+
+// ValidateWebAppData verifies the initData string passed by Telegram to a Mini App,
+// see https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app.
+// Data signed longer than maxAge ago is rejected, a zero maxAge disables the check.
+func ValidateWebAppData(initData, botToken string, maxAge time.Duration, now time.Time) (*WebAppUser, error) {
+	if initData == "" {
+		return nil, ErrInitDataMissing
+	}
+
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, ErrInitDataMalformed
+	}
+
+	hash, err := hex.DecodeString(values.Get("hash"))
+	if err != nil || len(hash) == 0 {
+		return nil, ErrInitDataSignature
+	}
+
+	// The data-check-string is every field but hash as key=value, sorted by key
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + values.Get(key)
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+
+	signature := hmac.New(sha256.New, secret.Sum(nil))
+	signature.Write([]byte(strings.Join(pairs, "\n")))
+
+	if !hmac.Equal(signature.Sum(nil), hash) {
+		return nil, ErrInitDataSignature
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, ErrInitDataMalformed
+	}
+
+	if maxAge > 0 && now.Sub(time.Unix(authDate, 0)) > maxAge {
+		return nil, ErrInitDataExpired
+	}
+
+	var user WebAppUser
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.ID == 0 {
+		return nil, ErrInitDataMalformed
+	}
+
+	return &user, nil
+}
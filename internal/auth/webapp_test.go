@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:test-token"
+
+// signInitData builds initData signed with token the way Telegram does
+func signInitData(values url.Values, token string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + values.Get(key)
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(token))
+
+	signature := hmac.New(sha256.New, secret.Sum(nil))
+	signature.Write([]byte(strings.Join(pairs, "\n")))
+
+	signed := url.Values{}
+	for key := range values {
+		signed.Set(key, values.Get(key))
+	}
+	signed.Set("hash", hex.EncodeToString(signature.Sum(nil)))
+	return signed.Encode()
+}
+
+// tamper replaces one field of signed initData keeping the signature
+func tamper(initData, key, value string) string {
+	values, _ := url.ParseQuery(initData)
+	values.Set(key, value)
+	return values.Encode()
+}
+
+func TestValidateWebAppData(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	fields := func(authDate time.Time, user string) url.Values {
+		return url.Values{
+			"auth_date": {strconv.FormatInt(authDate.Unix(), 10)},
+			"query_id":  {"AAH"},
+			"user":      {user},
+		}
+	}
+	validUser := `{"id":42,"first_name":"Ivan","username":"ivan"}`
+
+	tests := []struct {
+		name     string
+		initData string
+		maxAge   time.Duration
+		wantID   int64
+		wantErr  error
+	}{
+		{
+			name:     "valid",
+			initData: signInitData(fields(now.Add(-time.Minute), validUser), testBotToken),
+			maxAge:   time.Hour,
+			wantID:   42,
+		},
+		{
+			name:     "expiry disabled",
+			initData: signInitData(fields(now.Add(-48*time.Hour), validUser), testBotToken),
+			wantID:   42,
+		},
+		{
+			name:    "missing",
+			wantErr: ErrInitDataMissing,
+		},
+		{
+			name:     "malformed query",
+			initData: "%zz",
+			wantErr:  ErrInitDataMalformed,
+		},
+		{
+			name:     "no hash",
+			initData: fields(now, validUser).Encode(),
+			wantErr:  ErrInitDataSignature,
+		},
+		{
+			name:     "hash is not hex",
+			initData: fields(now, validUser).Encode() + "&hash=xyz",
+			wantErr:  ErrInitDataSignature,
+		},
+		{
+			name:     "other bot token",
+			initData: signInitData(fields(now, validUser), "654321:other-token"),
+			wantErr:  ErrInitDataSignature,
+		},
+		{
+			name:     "tampered user",
+			initData: tamper(signInitData(fields(now, validUser), testBotToken), "user", `{"id":43}`),
+			wantErr:  ErrInitDataSignature,
+		},
+		{
+			name:     "expired",
+			initData: signInitData(fields(now.Add(-2*time.Hour), validUser), testBotToken),
+			maxAge:   time.Hour,
+			wantErr:  ErrInitDataExpired,
+		},
+		{
+			name:     "bad auth date",
+			initData: signInitData(url.Values{"auth_date": {"yesterday"}, "user": {validUser}}, testBotToken),
+			wantErr:  ErrInitDataMalformed,
+		},
+		{
+			name:     "user without id",
+			initData: signInitData(fields(now, `{"first_name":"Ivan"}`), testBotToken),
+			wantErr:  ErrInitDataMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := ValidateWebAppData(tt.initData, testBotToken, tt.maxAge, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateWebAppData() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && user.ID != tt.wantID {
+				t.Errorf("ValidateWebAppData() user ID = %d, want %d", user.ID, tt.wantID)
+			}
+		})
+	}
+}
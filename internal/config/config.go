@@ -13,18 +13,50 @@ type Config struct {
 	Env           string        `yaml:"env" env-default:"prod"`
 	StorageConfig StorageConfig `yaml:"storage" env-required:"true"`
 	BotConfig     BotConfig     `yaml:"bot" env-required:"true"`
+	WebAppConfig  WebAppConfig  `yaml:"web_app"`
 	EmailConfig   EmailConfig   `yaml:"email" env-required:"true"`
 	RedisConfig   RedisConfig   `yaml:"redis" env-required:"true"`
+	FormulaConfig FormulaConfig `yaml:"formula"`
+	FSMConfig     FSMConfig     `yaml:"fsm"`
 }
 
 type StorageConfig struct {
 	DatabaseUrl string `yaml:"database_url" env-required:"true"`
 }
 
+// Modes of receiving bot updates
+const (
+	BotModePolling = "polling"
+	BotModeWebhook = "webhook"
+)
+
 type BotConfig struct {
-	Token   string `yaml:"token" env-required:"true"`
-	Timeout int    `yaml:"timeout" env-default:"60"`
-	Debug   bool   `yaml:"debug" env-default:"false"`
+	Token   string        `yaml:"token" env-required:"true"`
+	Timeout int           `yaml:"timeout" env-default:"60"`
+	Debug   bool          `yaml:"debug" env-default:"false"`
+	Mode    string        `yaml:"mode" env:"BOT_MODE" env-default:"polling"`
+	Webhook WebhookConfig `yaml:"webhook"`
+	// Workers is how many updates are handled at once, updates of a chat are handled in order
+	Workers       int           `yaml:"workers" env-default:"16"`
+	QueueSize     int           `yaml:"queue_size" env-default:"64"`
+	UpdateTimeout time.Duration `yaml:"update_timeout" env-default:"30s"`
+}
+
+// WebhookConfig configures receiving updates by webhook, see https://core.telegram.org/bots/api#setwebhook
+type WebhookConfig struct {
+	// URL is the public URL Telegram posts the updates to
+	URL string `yaml:"url" env:"BOT_WEBHOOK_URL"`
+	// Listen is the address of the webhook server
+	Listen             string   `yaml:"listen" env-default:":8443"`
+	SecretToken        string   `yaml:"secret_token" env:"BOT_WEBHOOK_SECRET"`
+	MaxConnections     int      `yaml:"max_connections" env-default:"40"`
+	DropPendingUpdates bool     `yaml:"drop_pending_updates" env-default:"false"`
+	AllowedUpdates     []string `yaml:"allowed_updates"`
+}
+
+type WebAppConfig struct {
+	URL  string `yaml:"url"`
+	Port int    `yaml:"port" env-default:"8081"`
 }
 
 type EmailConfig struct {
@@ -40,6 +72,31 @@ type EmailConfig struct {
 	Debug       bool   `yaml:"debug" env-default:"false"`
 }
 
+type FormulaConfig struct {
+	BaseURL string `yaml:"base_url" env-default:"https://latex.codecogs.com/png.image?"`
+	DPI     int    `yaml:"dpi" env-default:"200"`
+}
+
+// Storages of the FSM state
+const (
+	FSMStorageMemory   = "memory"
+	FSMStorageRedis    = "redis"
+	FSMStoragePostgres = "postgres"
+)
+
+// FSMConfig configures where the FSM state is kept and how long the bot waits for the user in a state
+type FSMConfig struct {
+	// Storage is memory, redis or postgres. Only postgres commits the state
+	// together with the verification codes.
+	Storage       string        `yaml:"storage" env:"FSM_STORAGE" env-default:"redis"`
+	LoginReminder time.Duration `yaml:"login_reminder" env-default:"5m"`
+	LoginTimeout  time.Duration `yaml:"login_timeout" env-default:"30m"`
+	OTPReminder   time.Duration `yaml:"otp_reminder" env-default:"10m"`
+	OTPTimeout    time.Duration `yaml:"otp_timeout" env-default:"30m"`
+	// PollInterval is how often the due reminders and timeouts are checked
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+}
+
 type RedisConfig struct {
 	Addr          string
 	Password      string
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"strings"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -8,3 +10,11 @@ type Bot struct {
 	Telegram      *tgbotapi.BotAPI
 	UpdateChannel tgbotapi.UpdatesChannel
 }
+
+// DisplayName is the name a Telegram user is shown with in games
+func DisplayName(user *tgbotapi.User) string {
+	if user.UserName != "" {
+		return user.UserName
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
@@ -10,20 +10,83 @@ type Question struct {
 	QuizID   string    `json:"quiz_id"`
 	
 	Text       string    `json:"text"`
+	TextHTML   string    `json:"text_html,omitempty"`
 	TimeLimit  int       `json:"time_limit"`
 	Points     int       `json:"points"`
+	PointsMode PointsMode `json:"points_mode"`
+
+	// IsBonus questions only count when answered correctly:
+	// they are left out of the maximum score
+	IsBonus bool `json:"is_bonus"`
+
+	Difficulty Difficulty `json:"difficulty,omitempty"`
+
+	// PoolID is set when the question is drawn from a quiz pool
+	// instead of being asked in every session
+	PoolID string `json:"pool_id,omitempty"`
 
 	Options   []Option    `json:"options"`
 }
 
+// PointsMode scales the points of a question
+type PointsMode string
+
+const (
+	PointsStandard PointsMode = "standard"
+	PointsDouble   PointsMode = "double"
+	PointsNone     PointsMode = "none"
+)
+
+// Multiplier returns the factor applied to the question points
+func (m PointsMode) Multiplier() int {
+	switch m {
+	case PointsDouble:
+		return 2
+	case PointsNone:
+		return 0
+	default:
+		return 1
+	}
+}
+
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium"
+	DifficultyHard   Difficulty = "hard"
+)
+
 type Option struct {
 	ID         string `json:"id"`
 	QuestionID string `json:"question_id"`
 	Text         string `json:"text"`
+	TextHTML     string `json:"text_html,omitempty"`
 	IsCorrect    bool   `json:"is_correct"`
 	Position     int	 `json:"position"`
 }
 
+// Validate checks the enumerated fields of a question
+func (q *Question) Validate() error {
+	switch q.PointsMode {
+	case "", PointsStandard, PointsDouble, PointsNone:
+	default:
+		return fmt.Errorf("unknown points mode: %s", q.PointsMode)
+	}
+
+	switch q.Difficulty {
+	case "", DifficultyEasy, DifficultyMedium, DifficultyHard:
+	default:
+		return fmt.Errorf("unknown difficulty: %s", q.Difficulty)
+	}
+
+	if q.Points < 0 {
+		return fmt.Errorf("points must not be negative")
+	}
+
+	return nil
+}
+
 type QuestionNotFoundError struct {
 	UUID string
 }
@@ -0,0 +1,44 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/domain/models/question"
+)
+
+// ErrForeignPool is returned when a question is put into a pool of another quiz
+var ErrForeignPool = errors.New("pool does not belong to the quiz of the question")
+
+// Pool is a bank of questions of a quiz from which every draw
+// takes DrawCount questions, e.g. 10 questions from a bank of 40
+type Pool struct {
+	ID        string `json:"id"`
+	QuizID    string `json:"quiz_id"`
+	Name      string `json:"name" binding:"required"`
+	DrawCount int    `json:"draw_count" binding:"required,min=1"`
+
+	// MinByDifficulty is the least number of drawn questions of a difficulty,
+	// e.g. {"hard": 3} for "at least 3 hard"
+	MinByDifficulty map[question.Difficulty]int `json:"min_by_difficulty,omitempty"`
+}
+
+// Validate checks that the pool constraints are consistent with each other
+func (p *Pool) Validate() error {
+	if p.DrawCount < 1 {
+		return fmt.Errorf("pool %q: draw count must be positive", p.Name)
+	}
+
+	required := 0
+	for difficulty, min := range p.MinByDifficulty {
+		if min < 0 {
+			return fmt.Errorf("pool %q: minimum of %s questions is negative", p.Name, difficulty)
+		}
+		required += min
+	}
+
+	if required > p.DrawCount {
+		return fmt.Errorf("pool %q: minimums add up to %d, more than the %d drawn questions", p.Name, required, p.DrawCount)
+	}
+
+	return nil
+}
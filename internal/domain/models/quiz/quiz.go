@@ -14,4 +14,5 @@ type Quiz struct {
 	CreatedAt time.Time           `json:"created_at"`
 	UpdatedAt time.Time           `json:"updated_at"`
 	Questions []question.Question `json:"questions,omitempty"`
+	Pools     []Pool              `json:"pools,omitempty"`
 }
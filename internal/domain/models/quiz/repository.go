@@ -23,4 +23,10 @@ type Repository interface {
 	Delete(ctx context.Context, uuid string) error
 	Quiz(ctx context.Context, uuid string) (*Quiz, error)
 	UserQuizzes(ctx context.Context, userId int64) ([]*Quiz, error)
-}
\ No newline at end of file
+}
+
+type PoolRepository interface {
+	QuizPools(ctx context.Context, quizUUID string) ([]Pool, error)
+	// SavePools replaces all pools of a quiz
+	SavePools(ctx context.Context, quizUUID string, pools []Pool) error
+}
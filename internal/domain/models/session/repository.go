@@ -0,0 +1,64 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrDuplicateAnswer is returned when a participant answers a question twice
+	ErrDuplicateAnswer = errors.New("question is already answered")
+	// ErrDuplicateParticipant is returned when a Telegram user joins a session twice
+	ErrDuplicateParticipant = errors.New("user has already joined the session")
+)
+
+type SessionNotFoundError struct {
+	ID string
+}
+
+func (e SessionNotFoundError) Error() string {
+	return fmt.Sprintf("session not found: %s", e.ID)
+}
+
+type ParticipantNotFoundError struct {
+	ID string
+}
+
+func (e ParticipantNotFoundError) Error() string {
+	return fmt.Sprintf("participant not found: %s", e.ID)
+}
+
+type Repository interface {
+	Create(ctx context.Context, session *Session) error
+	Update(
+		ctx context.Context,
+		sessionID string,
+		updateFn func(innerCtx context.Context, session *Session) error,
+	) error
+	Session(ctx context.Context, id string) (*Session, error)
+	SessionByJoinCode(ctx context.Context, joinCode string) (*Session, error)
+	// HostFinishedSessions returns the last finished sessions of a host, newest first
+	HostFinishedSessions(ctx context.Context, hostID int64, limit int) ([]*Session, error)
+
+	AddParticipant(ctx context.Context, participant *Participant) error
+	Participant(ctx context.Context, id string) (*Participant, error)
+	// ParticipantByTelegramID returns the participant of a Telegram user in a session
+	ParticipantByTelegramID(ctx context.Context, sessionID string, telegramID int64) (*Participant, error)
+	SessionParticipants(ctx context.Context, sessionID string) ([]*Participant, error)
+
+	// SaveDraw records the questions drawn from the quiz pools, in order.
+	// An empty participantID records the draw shared by the whole session.
+	SaveDraw(ctx context.Context, sessionID string, participantID string, questionIDs []string) error
+	// Draw returns the recorded question IDs in order, or nil if nothing was drawn
+	Draw(ctx context.Context, sessionID string, participantID string) ([]string, error)
+
+	SaveAnswer(ctx context.Context, answer *Answer) error
+	ParticipantAnswers(ctx context.Context, participantID string) ([]*Answer, error)
+	// SessionAnswers returns the answers of all participants given since a moment
+	SessionAnswers(ctx context.Context, sessionID string, since time.Time) ([]*Answer, error)
+
+	SaveFlags(ctx context.Context, flags []Flag) error
+	SessionFlags(ctx context.Context, sessionID string) ([]Flag, error)
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"time"
+)
+
+// Status is a bit mask of game session states, see game_sessions.status_flags
+type Status int
+
+const (
+	StatusWaiting  Status = 1 << iota // 1 (0001)
+	StatusActive                      // 2 (0010)
+	StatusPaused                      // 4 (0100)
+	StatusFinished                    // 8 (1000)
+)
+
+// DrawMode tells who gets their own draw of questions from the quiz pools
+type DrawMode string
+
+const (
+	DrawPerSession     DrawMode = "session"
+	DrawPerParticipant DrawMode = "participant"
+)
+
+// Settings are the host's choices for a single game session
+type Settings struct {
+	ShuffleQuestions bool     `json:"shuffle_questions"`
+	ShuffleOptions   bool     `json:"shuffle_options"`
+	PoolDraw         DrawMode `json:"pool_draw,omitempty"`
+}
+
+type Session struct {
+	ID                   string     `json:"id"`
+	QuizID               string     `json:"quiz_id"`
+	HostID               int64      `json:"host_id"`
+	JoinCode             string     `json:"join_code"`
+	Status               Status     `json:"status"`
+	CurrentQuestionIndex int        `json:"current_question_index"`
+	Settings             Settings   `json:"settings"`
+	QuestionOpenedAt     *time.Time `json:"question_opened_at,omitempty"`
+	StartedAt            *time.Time `json:"started_at,omitempty"`
+	EndedAt              *time.Time `json:"ended_at,omitempty"`
+}
+
+type Participant struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	UserID    *int64    `json:"user_id,omitempty"`
+	Login     string    `json:"login"`
+	Score     int       `json:"score"`
+	JoinedAt  time.Time `json:"joined_at"`
+
+	// TelegramID is set for participants who joined in the bot,
+	// a Telegram user has one participant per session
+	TelegramID *int64 `json:"-"`
+
+	// Seed makes the participant's question and option order reproducible
+	Seed int64 `json:"-"`
+}
+
+// Answer always refers to the canonical option ID, whatever order
+// the options were shown in
+type Answer struct {
+	ID             string    `json:"id"`
+	ParticipantID  string    `json:"participant_id"`
+	QuestionID     string    `json:"question_id"`
+	OptionID       string    `json:"option_id"`
+	IsCorrect      bool      `json:"is_correct"`
+	ResponseTimeMs int       `json:"response_time_ms"`
+	PointsAwarded  int       `json:"points_awarded"`
+	AnsweredAt     time.Time `json:"answered_at"`
+}
+
+// FlagKind is a suspicious answering pattern
+type FlagKind string
+
+const (
+	// FlagFastAnswer is an answer given faster than a human can read the question
+	FlagFastAnswer FlagKind = "fast_answer"
+	// FlagTimingCluster is an answer given at the same moment as several others
+	FlagTimingCluster FlagKind = "timing_cluster"
+)
+
+// Flag marks a participant's answer for review in the post-game report
+type Flag struct {
+	ID            string    `json:"id"`
+	SessionID     string    `json:"session_id"`
+	ParticipantID string    `json:"participant_id"`
+	QuestionID    string    `json:"question_id,omitempty"`
+	Kind          FlagKind  `json:"kind"`
+	Details       string    `json:"details"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -1,7 +1,8 @@
 package models
 
 type User struct {
-	ID    int64  `json:"id"`
-	Login string `json:"login"`
-	Role  int64  `json:"role"`
+	ID         int64  `json:"id"`
+	TelegramID int64  `json:"telegram_id,omitempty"`
+	Login      string `json:"login"`
+	Role       int64  `json:"role"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VerificationCode is a one-time code sent to the email of a user who registers in the bot
+type VerificationCode struct {
+	ID         uuid.UUID `json:"id"`
+	TelegramID int64     `json:"telegram_id"`
+	Code       string    `json:"code"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package clients
+
+import (
+	"fmt"
+	"kahoot_bsu/internal/config"
+	"net/url"
+)
+
+// FormulaClient links LaTeX formulas to PNG images of an external rendering
+// service that takes the formula in the query string, the image is rendered
+// when Telegram or the user opens the link
+type FormulaClient struct {
+	BaseURL string
+	DPI     int
+}
+
+func NewFormulaClient(cfg config.FormulaConfig) *FormulaClient {
+	return &FormulaClient{
+		BaseURL: cfg.BaseURL,
+		DPI:     cfg.DPI,
+	}
+}
+
+// ImageURL returns the URL of the formula image
+func (c *FormulaClient) ImageURL(formula string) (string, error) {
+	if formula == "" {
+		return "", fmt.Errorf("empty formula")
+	}
+
+	if c.DPI > 0 {
+		formula = fmt.Sprintf(`\dpi{%d} %s`, c.DPI, formula)
+	}
+
+	return c.BaseURL + url.QueryEscape(formula), nil
+}
@@ -0,0 +1,47 @@
+package clients
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestFormulaClientImageURL(t *testing.T) {
+	client := &FormulaClient{BaseURL: "https://latex.example/png.image?"}
+
+	tests := []struct {
+		formula string
+		dpi     int
+		want    string
+	}{
+		{formula: "a+b", want: "a+b"},
+		{formula: "x & y", want: "x & y"},
+		{formula: `\frac{1}{2}`, dpi: 200, want: `\dpi{200} \frac{1}{2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formula, func(t *testing.T) {
+			client.DPI = tt.dpi
+
+			imageURL, err := client.ImageURL(tt.formula)
+			if err != nil {
+				t.Fatalf("ImageURL(%q) error = %v", tt.formula, err)
+			}
+
+			parsed, err := url.Parse(imageURL)
+			if err != nil {
+				t.Fatalf("ImageURL(%q) = %q is not a URL: %v", tt.formula, imageURL, err)
+			}
+			got, err := url.QueryUnescape(parsed.RawQuery)
+			if err != nil {
+				t.Fatalf("ImageURL(%q) query %q: %v", tt.formula, parsed.RawQuery, err)
+			}
+			if got != tt.want {
+				t.Errorf("ImageURL(%q) query = %q, want %q", tt.formula, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := client.ImageURL(""); err == nil {
+		t.Error("ImageURL(\"\") error = nil, want error")
+	}
+}
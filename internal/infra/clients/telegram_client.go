@@ -0,0 +1,30 @@
+package clients
+
+import (
+	"context"
+	"kahoot_bsu/internal/ports"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TelegramClient sends messages through the Telegram Bot API
+type TelegramClient struct {
+	bot *tgbotapi.BotAPI
+}
+
+func NewTelegramClient(bot *tgbotapi.BotAPI) ports.Messenger {
+	return &TelegramClient{bot: bot}
+}
+
+func (c *TelegramClient) SendPhoto(ctx context.Context, chatID int64, photo []byte, caption string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "photo.png", Bytes: photo})
+	msg.Caption = caption
+	msg.ParseMode = tgbotapi.ModeHTML
+
+	_, err := c.bot.Send(msg)
+	return err
+}
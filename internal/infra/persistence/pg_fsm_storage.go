@@ -0,0 +1,168 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/domain/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgStorage implements Storage on the registation_fsm table
+type PgStorage struct {
+	conn *pgxpool.Pool
+}
+
+// NewPgStorage creates a new PostgreSQL-based storage
+func NewPgStorage(conn *pgxpool.Pool) *PgStorage {
+	return &PgStorage{
+		conn: conn,
+	}
+}
+
+// InTx implements fsm.Transactional.InTx. The repositories called with the
+// context passed to fn write in the same transaction.
+func (s *PgStorage) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, s.conn, fn)
+}
+
+// Get implements Storage.Get
+func (s *PgStorage) Get(ctx context.Context, chatID int64, userID int64) (models.State, error) {
+	var state string
+	err := querierFrom(ctx, s.conn).QueryRow(ctx,
+		"SELECT state FROM registation_fsm WHERE chat_id = $1 AND user_id = $2",
+		chatID, userID,
+	).Scan(&state)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.DefaultState, nil
+	} else if err != nil {
+		return models.DefaultState, fmt.Errorf("failed to get state: %w", err)
+	}
+
+	return models.State(state), nil
+}
+
+// Set implements Storage.Set
+func (s *PgStorage) Set(ctx context.Context, chatID int64, userID int64, state models.State) error {
+	_, err := querierFrom(ctx, s.conn).Exec(ctx,
+		`INSERT INTO registation_fsm (chat_id, user_id, state)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (chat_id, user_id) DO UPDATE SET state = EXCLUDED.state, updated_at = NOW()`,
+		chatID, userID, string(state),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set state: %w", err)
+	}
+	return nil
+}
+
+// CompareAndSet implements Storage.CompareAndSet, the row of the user is locked
+// until the transaction of ctx ends
+func (s *PgStorage) CompareAndSet(ctx context.Context, chatID int64, userID int64, from, to models.State) (bool, error) {
+	set := false
+	err := s.InTx(ctx, func(ctx context.Context) error {
+		tx := querierFrom(ctx, s.conn)
+
+		// Lock the row, or the key of a missing row so two first transitions wait for each other
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO registation_fsm (chat_id, user_id) VALUES ($1, $2) ON CONFLICT (chat_id, user_id) DO NOTHING",
+			chatID, userID,
+		); err != nil {
+			return fmt.Errorf("failed to create state: %w", err)
+		}
+
+		var current string
+		if err := tx.QueryRow(ctx,
+			"SELECT state FROM registation_fsm WHERE chat_id = $1 AND user_id = $2 FOR UPDATE",
+			chatID, userID,
+		).Scan(&current); err != nil {
+			return fmt.Errorf("failed to lock state: %w", err)
+		}
+
+		if models.State(current) != from {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx,
+			"UPDATE registation_fsm SET state = $3, updated_at = NOW() WHERE chat_id = $1 AND user_id = $2",
+			chatID, userID, string(to),
+		); err != nil {
+			return fmt.Errorf("failed to set state: %w", err)
+		}
+
+		set = true
+		return nil
+	})
+
+	return set, err
+}
+
+// Delete implements Storage.Delete. The data is kept like in the other storages.
+func (s *PgStorage) Delete(ctx context.Context, chatID int64, userID int64) error {
+	_, err := querierFrom(ctx, s.conn).Exec(ctx,
+		"UPDATE registation_fsm SET state = '', updated_at = NOW() WHERE chat_id = $1 AND user_id = $2",
+		chatID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete state: %w", err)
+	}
+	return nil
+}
+
+// GetData implements Storage.GetData
+func (s *PgStorage) GetData(ctx context.Context, chatID int64, userID int64, key string) (interface{}, error) {
+	var raw []byte
+	err := querierFrom(ctx, s.conn).QueryRow(ctx,
+		"SELECT data -> $3 FROM registation_fsm WHERE chat_id = $1 AND user_id = $2",
+		chatID, userID, key,
+	).Scan(&raw)
+
+	if errors.Is(err, pgx.ErrNoRows) || raw == nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get data: %w", err)
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	return result, nil
+}
+
+// SetData implements Storage.SetData
+func (s *PgStorage) SetData(ctx context.Context, chatID int64, userID int64, key string, value interface{}) error {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	_, err = querierFrom(ctx, s.conn).Exec(ctx,
+		`INSERT INTO registation_fsm (chat_id, user_id, data)
+		VALUES ($1, $2, jsonb_build_object($3::text, $4::jsonb))
+		ON CONFLICT (chat_id, user_id) DO UPDATE
+		SET data = registation_fsm.data || EXCLUDED.data, updated_at = NOW()`,
+		chatID, userID, key, string(jsonData),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set data: %w", err)
+	}
+	return nil
+}
+
+// ClearData implements Storage.ClearData
+func (s *PgStorage) ClearData(ctx context.Context, chatID int64, userID int64) error {
+	_, err := querierFrom(ctx, s.conn).Exec(ctx,
+		"UPDATE registation_fsm SET data = '{}', updated_at = NOW() WHERE chat_id = $1 AND user_id = $2",
+		chatID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type pgPoolRepository struct {
+	conn *pgxpool.Pool
+}
+
+// NewPgPoolRepository creates a new PostgreSQL-based question pool repository
+func NewPgPoolRepository(conn *pgxpool.Pool) quiz.PoolRepository {
+	return &pgPoolRepository{
+		conn: conn,
+	}
+}
+
+// QuizPools retrieves all pools of a quiz
+func (r *pgPoolRepository) QuizPools(ctx context.Context, quizUUID string) ([]quiz.Pool, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT id, quiz_id, name, draw_count, min_by_difficulty
+		FROM question_pools
+		WHERE quiz_id = $1
+		ORDER BY name
+	`, quizUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch quiz pools: %w", err)
+	}
+	defer rows.Close()
+
+	var pools []quiz.Pool
+	for rows.Next() {
+		var p quiz.Pool
+		if err := rows.Scan(&p.ID, &p.QuizID, &p.Name, &p.DrawCount, &p.MinByDifficulty); err != nil {
+			return nil, fmt.Errorf("failed to scan pool row: %w", err)
+		}
+		pools = append(pools, p)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error iterating through pools: %w", rows.Err())
+	}
+
+	return pools, nil
+}
+
+// SavePools replaces the pools of a quiz. Pools are matched by ID,
+// so questions keep their pool when it is only edited.
+func (r *pgPoolRepository) SavePools(ctx context.Context, quizUUID string, pools []quiz.Pool) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]string, 0, len(pools))
+	for _, p := range pools {
+		ids = append(ids, p.ID)
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM question_pools
+		WHERE quiz_id = $1 AND NOT (id = ANY($2::uuid[]))
+	`, quizUUID, ids)
+	if err != nil {
+		return fmt.Errorf("failed to delete removed pools: %w", err)
+	}
+
+	for i := range pools {
+		p := &pools[i]
+		p.QuizID = quizUUID
+
+		minByDifficulty := p.MinByDifficulty
+		if minByDifficulty == nil {
+			minByDifficulty = map[question.Difficulty]int{}
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO question_pools (id, quiz_id, name, draw_count, min_by_difficulty)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (id) DO UPDATE
+			SET name = EXCLUDED.name,
+				draw_count = EXCLUDED.draw_count,
+				min_by_difficulty = EXCLUDED.min_by_difficulty
+			WHERE question_pools.quiz_id = EXCLUDED.quiz_id
+		`, p.ID, p.QuizID, p.Name, p.DrawCount, minByDifficulty)
+		if err != nil {
+			return fmt.Errorf("failed to save pool: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -23,7 +23,7 @@ func NewPgQuestionRepository(conn *pgxpool.Pool) question.Repository {
 
 // Create adds a new question to a quiz
 func (r *pgQuestionRepository) Create(ctx context.Context, q *question.Question) error {
-	tx, err := r.conn.Begin(ctx)
+	tx, err := beginTx(ctx, r.conn)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -36,12 +36,21 @@ func (r *pgQuestionRepository) Create(ctx context.Context, q *question.Question)
 	if q.Points == 0 {
 		q.Points = 100
 	}
+	if q.Difficulty == "" {
+		q.Difficulty = question.DifficultyMedium
+	}
+	if q.PointsMode == "" {
+		q.PointsMode = question.PointsStandard
+	}
 
-	// Insert question
+	// Insert question after the last one of the quiz, the position orders
+	// the questions of shuffles and draws
 	_, err = tx.Exec(ctx, `
-		INSERT INTO questions (uuid, quiz_uuid, text, time_limit, points, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, q.ID, q.QuizID, q.Text, q.TimeLimit, q.Points)
+		INSERT INTO questions (uuid, quiz_uuid, text, time_limit, points, points_mode, is_bonus, difficulty, pool_id, position)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE(MAX(position), -1) + 1
+		FROM questions
+		WHERE quiz_uuid = $2
+	`, q.ID, q.QuizID, q.Text, q.TimeLimit, q.Points, q.PointsMode, q.IsBonus, q.Difficulty, nullableString(q.PoolID))
 	if err != nil {
 		return fmt.Errorf("failed to insert question: %w", err)
 	}
@@ -51,10 +60,12 @@ func (r *pgQuestionRepository) Create(ctx context.Context, q *question.Question)
 		option := &q.Options[i]
 		option.QuestionID = q.ID
 
+		option.Position = i
+
 		_, err = tx.Exec(ctx, `
-			INSERT INTO options (uuid, question_uuid, text, is_correct)
-			VALUES ($1, $2, $3, $4)
-		`, option.ID, option.QuestionID, option.Text, option.IsCorrect)
+			INSERT INTO options (uuid, question_uuid, text, is_correct, position)
+			VALUES ($1, $2, $3, $4, $5)
+		`, option.ID, option.QuestionID, option.Text, option.IsCorrect, option.Position)
 		if err != nil {
 			return fmt.Errorf("failed to insert option: %w", err)
 		}
@@ -90,9 +101,12 @@ func (r *pgQuestionRepository) Update(
 	// Update the question
 	_, err = tx.Exec(ctx, `
 		UPDATE questions 
-		SET text = $1, time_limit = $2, points = $3, updated_at = $4
-		WHERE uuid = $5
-	`, existingQuestion.Text, existingQuestion.TimeLimit, existingQuestion.Points, questionUUID)
+		SET text = $1, time_limit = $2, points = $3, points_mode = $4, is_bonus = $5,
+			difficulty = $6, pool_id = $7
+		WHERE uuid = $8
+	`, existingQuestion.Text, existingQuestion.TimeLimit, existingQuestion.Points,
+		existingQuestion.PointsMode, existingQuestion.IsBonus,
+		existingQuestion.Difficulty, nullableString(existingQuestion.PoolID), questionUUID)
 	if err != nil {
 		return fmt.Errorf("failed to update question: %w", err)
 	}
@@ -109,7 +123,7 @@ func (r *pgQuestionRepository) Update(
 // Delete removes a question by UUID
 func (r *pgQuestionRepository) Delete(ctx context.Context, uuid string) error {
 	// The database should handle cascading deletes for options
-	_, err := r.conn.Exec(ctx, "DELETE FROM questions WHERE uuid = $1", uuid)
+	_, err := querierFrom(ctx, r.conn).Exec(ctx, "DELETE FROM questions WHERE uuid = $1", uuid)
 	if err != nil {
 		return fmt.Errorf("failed to delete question: %w", err)
 	}
@@ -128,10 +142,10 @@ func (r *pgQuestionRepository) Question(ctx context.Context, uuid string) (*ques
 // QuizQuestions retrieves all questions for a specific quiz
 func (r *pgQuestionRepository) QuizQuestions(ctx context.Context, quizID string) ([]*question.Question, error) {
 	rows, err := r.conn.Query(ctx, `
-		SELECT uuid, quiz_uuid, text, time_limit, points, created_at, updated_at
+		SELECT uuid, quiz_uuid, text, time_limit, points, points_mode, is_bonus, difficulty, pool_id
 		FROM questions
 		WHERE quiz_uuid = $1
-		ORDER BY created_at
+		ORDER BY position, uuid
 	`, quizID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch quiz questions: %w", err)
@@ -192,12 +206,15 @@ func (r *pgQuestionRepository) getQuestion(ctx context.Context, uuid string) (*q
 
 // getQuestionWithTx retrieves a question by UUID within a transaction
 func (r *pgQuestionRepository) getQuestionWithTx(ctx context.Context, tx pgx.Tx, uuid string) (*question.Question, error) {
-	var q question.Question
+	var (
+		q      question.Question
+		poolID *string
+	)
 	err := tx.QueryRow(ctx, `
-		SELECT uuid, quiz_uuid, text, time_limit, points, created_at, updated_at
+		SELECT uuid, quiz_uuid, text, time_limit, points, points_mode, is_bonus, difficulty, pool_id
 		FROM questions
 		WHERE uuid = $1
-	`, uuid).Scan(&q.ID, &q.QuizID, &q.Text, &q.TimeLimit, &q.Points)
+	`, uuid).Scan(&q.ID, &q.QuizID, &q.Text, &q.TimeLimit, &q.Points, &q.PointsMode, &q.IsBonus, &q.Difficulty, &poolID)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -205,6 +222,9 @@ func (r *pgQuestionRepository) getQuestionWithTx(ctx context.Context, tx pgx.Tx,
 		}
 		return nil, fmt.Errorf("failed to retrieve question: %w", err)
 	}
+	if poolID != nil {
+		q.PoolID = *poolID
+	}
 
 	// Load options
 	options, err := r.getOptionsWithTx(ctx, tx, uuid)
@@ -220,16 +240,26 @@ func (r *pgQuestionRepository) getQuestionWithTx(ctx context.Context, tx pgx.Tx,
 func (r *pgQuestionRepository) scanQuestionsRows(ctx context.Context, rows pgx.Rows) ([]*question.Question, error) {
 	var questions []*question.Question
 	for rows.Next() {
-		var q question.Question
+		var (
+			q      question.Question
+			poolID *string
+		)
 		if err := rows.Scan(
 			&q.ID,
 			&q.QuizID,
 			&q.Text,
 			&q.TimeLimit,
 			&q.Points,
+			&q.PointsMode,
+			&q.IsBonus,
+			&q.Difficulty,
+			&poolID,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan question row: %w", err)
 		}
+		if poolID != nil {
+			q.PoolID = *poolID
+		}
 
 		// Load options for this question
 		options, err := r.getOptions(ctx, q.ID)
@@ -251,9 +281,10 @@ func (r *pgQuestionRepository) scanQuestionsRows(ctx context.Context, rows pgx.R
 // getOptions loads options for a question
 func (r *pgQuestionRepository) getOptions(ctx context.Context, questionUUID string) ([]question.Option, error) {
 	rows, err := r.conn.Query(ctx, `
-		SELECT uuid, question_uuid, text, is_correct
+		SELECT uuid, question_uuid, text, is_correct, position
 		FROM options
 		WHERE question_uuid = $1
+		ORDER BY position, uuid
 	`, questionUUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch question options: %w", err)
@@ -266,9 +297,10 @@ func (r *pgQuestionRepository) getOptions(ctx context.Context, questionUUID stri
 // getOptionsWithTx loads options for a question within a transaction
 func (r *pgQuestionRepository) getOptionsWithTx(ctx context.Context, tx pgx.Tx, questionUUID string) ([]question.Option, error) {
 	rows, err := tx.Query(ctx, `
-		SELECT uuid, question_uuid, text, is_correct
+		SELECT uuid, question_uuid, text, is_correct, position
 		FROM options
 		WHERE question_uuid = $1
+		ORDER BY position, uuid
 	`, questionUUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch question options: %w", err)
@@ -288,6 +320,7 @@ func (r *pgQuestionRepository) scanOptionsRows(rows pgx.Rows) ([]question.Option
 			&opt.QuestionID,
 			&opt.Text,
 			&opt.IsCorrect,
+			&opt.Position,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan option row: %w", err)
 		}
@@ -314,10 +347,12 @@ func (r *pgQuestionRepository) updateOptionsWithTx(ctx context.Context, tx pgx.T
 		option := &options[i]
 		option.QuestionID = questionUUID
 
+		option.Position = i
+
 		_, err = tx.Exec(ctx, `
-			INSERT INTO options (uuid, question_uuid, text, is_correct)
-			VALUES ($1, $2, $3, $4)
-		`, option.ID, option.QuestionID, option.Text, option.IsCorrect)
+			INSERT INTO options (uuid, question_uuid, text, is_correct, position)
+			VALUES ($1, $2, $3, $4, $5)
+		`, option.ID, option.QuestionID, option.Text, option.IsCorrect, option.Position)
 		if err != nil {
 			return fmt.Errorf("failed to insert option: %w", err)
 		}
@@ -49,7 +49,7 @@ func (r *pgQuizRepository) Update(
 	_, err = tx.Exec(ctx, `
 		UPDATE quizzes 
 		SET title = $1, updated_at = $2
-		WHERE uuid = $3
+		WHERE id = $3
 	`, existingQuiz.Title, time.Now(), quizUUID)
 	if err != nil {
 		return fmt.Errorf("failed to update quiz: %w", err)
@@ -65,7 +65,7 @@ func (r *pgQuizRepository) Update(
 
 // UpdateOrCreate updates an existing quiz or creates a new one if it doesn't exist
 func (r *pgQuizRepository) UpdateOrCreate(ctx context.Context, quiz *quiz.Quiz) error {
-	tx, err := r.conn.Begin(ctx)
+	tx, err := beginTx(ctx, r.conn)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -83,9 +83,9 @@ func (r *pgQuizRepository) UpdateOrCreate(ctx context.Context, quiz *quiz.Quiz)
 		// Update quiz
 		_, err = tx.Exec(ctx, `
 			UPDATE quizzes 
-			SET title = $1, updated_at = $2
-			WHERE id = $3
-		`, quiz.Title, now, quiz.ID)
+			SET title = $1, is_public = $2, updated_at = $3
+			WHERE id = $4
+		`, quiz.Title, quiz.IsPublic, now, quiz.ID)
 		if err != nil {
 			return fmt.Errorf("failed to update quiz: %w", err)
 		}
@@ -98,9 +98,9 @@ func (r *pgQuizRepository) UpdateOrCreate(ctx context.Context, quiz *quiz.Quiz)
 	} else {
 		// Create new quiz
 		_, err = tx.Exec(ctx, `
-			INSERT INTO quizzes (id, user_id, title, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5)
-		`, quiz.ID, quiz.UserID, quiz.Title, now, now)
+			INSERT INTO quizzes (id, user_id, title, is_public, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, quiz.ID, quiz.UserID, quiz.Title, quiz.IsPublic, quiz.CreatedBy, now, now)
 		if err != nil {
 			return fmt.Errorf("failed to create quiz: %w", err)
 		}
@@ -131,9 +131,9 @@ func (r *pgQuizRepository) Quiz(ctx context.Context, uuid string) (*quiz.Quiz, e
 // UserQuizzes retrieves all quizzes belonging to a specific user
 func (r *pgQuizRepository) UserQuizzes(ctx context.Context, userID int64) ([]*quiz.Quiz, error) {
 	rows, err := r.conn.Query(ctx, `
-		SELECT uuid, user_uuid, title, created_at, updated_at 
+		SELECT id, user_id::text, title, is_public, created_by, created_at, updated_at
 		FROM quizzes
-		WHERE user_uuid = $1
+		WHERE user_id = $1
 		ORDER BY updated_at DESC
 	`, userID)
 	if err != nil {
@@ -144,7 +144,7 @@ func (r *pgQuizRepository) UserQuizzes(ctx context.Context, userID int64) ([]*qu
 	var quizzes []*quiz.Quiz
 	for rows.Next() {
 		q := &quiz.Quiz{}
-		if err := rows.Scan(&q.ID, &q.UserID, &q.Title, &q.CreatedAt, &q.UpdatedAt); err != nil {
+		if err := rows.Scan(&q.ID, &q.UserID, &q.Title, &q.IsPublic, &q.CreatedBy, &q.CreatedAt, &q.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan quiz row: %w", err)
 		}
 		quizzes = append(quizzes, q)
@@ -190,10 +190,10 @@ func (r *pgQuizRepository) getQuiz(ctx context.Context, uuid string) (*quiz.Quiz
 func (r *pgQuizRepository) getQuizWithTx(ctx context.Context, tx pgx.Tx, uuid string) (*quiz.Quiz, error) {
 	var q quiz.Quiz
 	err := tx.QueryRow(ctx, `
-		SELECT uuid, user_uuid, title, created_at, updated_at
+		SELECT id, user_id::text, title, is_public, created_by, created_at, updated_at
 		FROM quizzes 
-		WHERE uuid = $1
-	`, uuid).Scan(&q.ID, &q.UserID, &q.Title, &q.CreatedAt, &q.UpdatedAt)
+		WHERE id = $1
+	`, uuid).Scan(&q.ID, &q.UserID, &q.Title, &q.IsPublic, &q.CreatedBy, &q.CreatedAt, &q.UpdatedAt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -213,10 +213,10 @@ func (r *pgQuizRepository) getQuizWithTx(ctx context.Context, tx pgx.Tx, uuid st
 // loadQuizQuestions loads questions and options for a quiz
 func (r *pgQuizRepository) loadQuizQuestions(ctx context.Context, q *quiz.Quiz) error {
 	rows, err := r.conn.Query(ctx, `
-		SELECT uuid, quiz_uuid, text, time_limit, points, created_at, updated_at
+		SELECT uuid, quiz_uuid, text, time_limit, points, points_mode, is_bonus, difficulty, pool_id
 		FROM questions
 		WHERE quiz_uuid = $1
-		ORDER BY created_at
+		ORDER BY position, uuid
 	`, q.ID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch quiz questions: %w", err)
@@ -229,10 +229,10 @@ func (r *pgQuizRepository) loadQuizQuestions(ctx context.Context, q *quiz.Quiz)
 // loadQuizQuestionsWithTx loads questions with transaction
 func (r *pgQuizRepository) loadQuizQuestionsWithTx(ctx context.Context, tx pgx.Tx, q *quiz.Quiz) error {
 	rows, err := tx.Query(ctx, `
-		SELECT uuid, quiz_uuid, text, time_limit, points, created_at, updated_at
+		SELECT uuid, quiz_uuid, text, time_limit, points, points_mode, is_bonus, difficulty, pool_id
 		FROM questions
 		WHERE quiz_uuid = $1
-		ORDER BY created_at
+		ORDER BY position, uuid
 	`, q.ID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch quiz questions: %w", err)
@@ -246,16 +246,26 @@ func (r *pgQuizRepository) loadQuizQuestionsWithTx(ctx context.Context, tx pgx.T
 func (r *pgQuizRepository) scanQuestionsRows(ctx context.Context, rows pgx.Rows, q *quiz.Quiz) error {
 	var questions []kahootQuestion.Question
 	for rows.Next() {
-		var question kahootQuestion.Question
+		var (
+			question kahootQuestion.Question
+			poolID   *string
+		)
 		if err := rows.Scan(
 			&question.ID,
 			&question.QuizID,
 			&question.Text,
 			&question.TimeLimit,
 			&question.Points,
+			&question.PointsMode,
+			&question.IsBonus,
+			&question.Difficulty,
+			&poolID,
 		); err != nil {
 			return fmt.Errorf("failed to scan question row: %w", err)
 		}
+		if poolID != nil {
+			question.PoolID = *poolID
+		}
 
 		// Load options for this question
 		if err := r.loadQuestionOptions(ctx, &question); err != nil {
@@ -276,9 +286,10 @@ func (r *pgQuizRepository) scanQuestionsRows(ctx context.Context, rows pgx.Rows,
 // loadQuestionOptions loads options for a question
 func (r *pgQuizRepository) loadQuestionOptions(ctx context.Context, question *kahootQuestion.Question) error {
 	rows, err := r.conn.Query(ctx, `
-		SELECT uuid, question_uuid, text, is_correct
+		SELECT uuid, question_uuid, text, is_correct, position
 		FROM options
 		WHERE question_uuid = $1
+		ORDER BY position, uuid
 	`, question.ID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch question options: %w", err)
@@ -288,7 +299,7 @@ func (r *pgQuizRepository) loadQuestionOptions(ctx context.Context, question *ka
 	var options []kahootQuestion.Option
 	for rows.Next() {
 		var option kahootQuestion.Option
-		if err := rows.Scan(&option.ID, &option.QuestionID, &option.Text, &option.IsCorrect); err != nil {
+		if err := rows.Scan(&option.ID, &option.QuestionID, &option.Text, &option.IsCorrect, &option.Position); err != nil {
 			return fmt.Errorf("failed to scan option row: %w", err)
 		}
 		options = append(options, option)
@@ -298,8 +309,7 @@ func (r *pgQuizRepository) loadQuestionOptions(ctx context.Context, question *ka
 		return fmt.Errorf("error iterating through options: %w", rows.Err())
 	}
 
-	// TODO: fix type compatibility
-	// question.Options = options
+	question.Options = options
 	return nil
 }
 
@@ -309,11 +319,19 @@ func (r *pgQuizRepository) updateQuizQuestions(ctx context.Context, tx pgx.Tx, q
 		question := &q.Questions[i]
 		question.QuizID = q.ID
 
+		if question.Difficulty == "" {
+			question.Difficulty = kahootQuestion.DifficultyMedium
+		}
+		if question.PointsMode == "" {
+			question.PointsMode = kahootQuestion.PointsStandard
+		}
+
 		// Insert question
 		_, err := tx.Exec(ctx, `
-			INSERT INTO questions (uuid, quiz_uuid, text, time_limit, points, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-		`, question.ID, question.QuizID, question.Text, question.TimeLimit, question.Points, time.Now(), time.Now())
+			INSERT INTO questions (uuid, quiz_uuid, text, time_limit, points, points_mode, is_bonus, difficulty, pool_id, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, question.ID, question.QuizID, question.Text, question.TimeLimit, question.Points,
+			question.PointsMode, question.IsBonus, question.Difficulty, nullableString(question.PoolID), i)
 		if err != nil {
 			return fmt.Errorf("failed to insert question: %w", err)
 		}
@@ -322,11 +340,12 @@ func (r *pgQuizRepository) updateQuizQuestions(ctx context.Context, tx pgx.Tx, q
 		for j := range question.Options {
 			option := &question.Options[j]
 			option.QuestionID = question.ID
+			option.Position = j
 
 			_, err := tx.Exec(ctx, `
-				INSERT INTO options (uuid, question_uuid, text, is_correct)
-				VALUES ($1, $2, $3, $4)
-			`, option.QuestionID, option.QuestionID, option.Text, option.IsCorrect)
+				INSERT INTO options (uuid, question_uuid, text, is_correct, position)
+				VALUES ($1, $2, $3, $4, $5)
+			`, option.ID, option.QuestionID, option.Text, option.IsCorrect, option.Position)
 			if err != nil {
 				return fmt.Errorf("failed to insert option: %w", err)
 			}
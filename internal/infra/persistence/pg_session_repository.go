@@ -0,0 +1,487 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/domain/models/session"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const sessionColumns = `
+	id, quiz_id, host_id, join_code, status_flags, current_question_index,
+	shuffle_questions, shuffle_options, pool_draw, question_opened_at, started_at, ended_at`
+
+const participantColumns = `id, session_id, user_id, telegram_id, login, score, seed, joined_at`
+
+const answerColumns = `
+	id, participant_id, question_id, option_id, is_correct,
+	response_time_ms, points_awarded, answered_at`
+
+// uniqueViolationCode is the PostgreSQL error code of unique_violation
+const uniqueViolationCode = "23505"
+
+type pgSessionRepository struct {
+	conn *pgxpool.Pool
+}
+
+// NewPgSessionRepository creates a new PostgreSQL-based game session repository,
+// AddParticipant and SaveDraw join the transaction of PgTransactor.InTx
+func NewPgSessionRepository(conn *pgxpool.Pool) session.Repository {
+	return &pgSessionRepository{
+		conn: conn,
+	}
+}
+
+// Create inserts a new game session
+func (r *pgSessionRepository) Create(ctx context.Context, s *session.Session) error {
+	_, err := r.conn.Exec(ctx, `
+		INSERT INTO game_sessions (`+sessionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, s.ID, s.QuizID, s.HostID, s.JoinCode, s.Status, s.CurrentQuestionIndex,
+		s.Settings.ShuffleQuestions, s.Settings.ShuffleOptions, s.Settings.PoolDraw,
+		s.QuestionOpenedAt, s.StartedAt, s.EndedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create game session: %w", err)
+	}
+	return nil
+}
+
+// Update updates a game session with the provided update function
+func (r *pgSessionRepository) Update(
+	ctx context.Context,
+	sessionID string,
+	updateFn func(innerCtx context.Context, session *session.Session) error,
+) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := scanSession(tx.QueryRow(ctx, `
+		SELECT `+sessionColumns+`
+		FROM game_sessions
+		WHERE id = $1
+		FOR UPDATE
+	`, sessionID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return session.SessionNotFoundError{ID: sessionID}
+		}
+		return fmt.Errorf("failed to retrieve game session: %w", err)
+	}
+
+	if err := updateFn(ctx, s); err != nil {
+		return fmt.Errorf("update function failed: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE game_sessions
+		SET status_flags = $1, current_question_index = $2,
+			shuffle_questions = $3, shuffle_options = $4, pool_draw = $5,
+			question_opened_at = $6, started_at = $7, ended_at = $8
+		WHERE id = $9
+	`, s.Status, s.CurrentQuestionIndex, s.Settings.ShuffleQuestions, s.Settings.ShuffleOptions,
+		s.Settings.PoolDraw, s.QuestionOpenedAt, s.StartedAt, s.EndedAt, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update game session: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Session retrieves a game session by ID
+func (r *pgSessionRepository) Session(ctx context.Context, id string) (*session.Session, error) {
+	s, err := scanSession(r.conn.QueryRow(ctx, `
+		SELECT `+sessionColumns+`
+		FROM game_sessions
+		WHERE id = $1
+	`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, session.SessionNotFoundError{ID: id}
+		}
+		return nil, fmt.Errorf("failed to retrieve game session: %w", err)
+	}
+	return s, nil
+}
+
+// SessionByJoinCode retrieves a game session by its join code
+func (r *pgSessionRepository) SessionByJoinCode(ctx context.Context, joinCode string) (*session.Session, error) {
+	s, err := scanSession(r.conn.QueryRow(ctx, `
+		SELECT `+sessionColumns+`
+		FROM game_sessions
+		WHERE join_code = $1
+	`, joinCode))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, session.SessionNotFoundError{ID: joinCode}
+		}
+		return nil, fmt.Errorf("failed to retrieve game session: %w", err)
+	}
+	return s, nil
+}
+
+// HostFinishedSessions retrieves the last finished game sessions of a host
+func (r *pgSessionRepository) HostFinishedSessions(ctx context.Context, hostID int64, limit int) ([]*session.Session, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT `+sessionColumns+`
+		FROM game_sessions
+		WHERE host_id = $1 AND status_flags & $2 <> 0
+		ORDER BY ended_at DESC NULLS LAST
+		LIMIT $3
+	`, hostID, session.StatusFinished, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch host sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*session.Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan game session row: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error iterating through game sessions: %w", rows.Err())
+	}
+
+	return sessions, nil
+}
+
+// AddParticipant registers a participant in a game session
+func (r *pgSessionRepository) AddParticipant(ctx context.Context, p *session.Participant) error {
+	err := querierFrom(ctx, r.conn).QueryRow(ctx, `
+		INSERT INTO participants (id, session_id, user_id, telegram_id, login, score, seed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING joined_at
+	`, p.ID, p.SessionID, p.UserID, p.TelegramID, p.Login, p.Score, p.Seed).Scan(&p.JoinedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return session.ErrDuplicateParticipant
+		}
+		return fmt.Errorf("failed to add participant: %w", err)
+	}
+	return nil
+}
+
+// Participant retrieves a participant by ID
+func (r *pgSessionRepository) Participant(ctx context.Context, id string) (*session.Participant, error) {
+	p, err := scanParticipant(r.conn.QueryRow(ctx, `
+		SELECT `+participantColumns+`
+		FROM participants
+		WHERE id = $1
+	`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, session.ParticipantNotFoundError{ID: id}
+		}
+		return nil, fmt.Errorf("failed to retrieve participant: %w", err)
+	}
+	return p, nil
+}
+
+// ParticipantByTelegramID retrieves the participant of a Telegram user in a session
+func (r *pgSessionRepository) ParticipantByTelegramID(ctx context.Context, sessionID string, telegramID int64) (*session.Participant, error) {
+	p, err := scanParticipant(r.conn.QueryRow(ctx, `
+		SELECT `+participantColumns+`
+		FROM participants
+		WHERE session_id = $1 AND telegram_id = $2
+	`, sessionID, telegramID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, session.ParticipantNotFoundError{ID: fmt.Sprintf("telegram user %d in session %s", telegramID, sessionID)}
+		}
+		return nil, fmt.Errorf("failed to retrieve participant: %w", err)
+	}
+	return p, nil
+}
+
+// SessionParticipants retrieves all participants of a game session ordered by score
+func (r *pgSessionRepository) SessionParticipants(ctx context.Context, sessionID string) ([]*session.Participant, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT `+participantColumns+`
+		FROM participants
+		WHERE session_id = $1
+		ORDER BY score DESC, joined_at
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch participants: %w", err)
+	}
+	defer rows.Close()
+
+	var participants []*session.Participant
+	for rows.Next() {
+		p, err := scanParticipant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan participant row: %w", err)
+		}
+		participants = append(participants, p)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error iterating through participants: %w", rows.Err())
+	}
+
+	return participants, nil
+}
+
+// SaveDraw records the questions drawn for a session or a participant
+func (r *pgSessionRepository) SaveDraw(ctx context.Context, sessionID string, participantID string, questionIDs []string) error {
+	tx, err := beginTx(ctx, r.conn)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for position, questionID := range questionIDs {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO session_draws (session_id, participant_id, question_id, position)
+			VALUES ($1, $2, $3, $4)
+		`, sessionID, nullableString(participantID), questionID, position)
+		if err != nil {
+			return fmt.Errorf("failed to insert drawn question: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Draw retrieves the questions drawn for a session or a participant in order
+func (r *pgSessionRepository) Draw(ctx context.Context, sessionID string, participantID string) ([]string, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT question_id
+		FROM session_draws
+		WHERE session_id = $1 AND participant_id IS NOT DISTINCT FROM $2::uuid
+		ORDER BY position
+	`, sessionID, nullableString(participantID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch drawn questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questionIDs []string
+	for rows.Next() {
+		var questionID string
+		if err := rows.Scan(&questionID); err != nil {
+			return nil, fmt.Errorf("failed to scan drawn question row: %w", err)
+		}
+		questionIDs = append(questionIDs, questionID)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error iterating through drawn questions: %w", rows.Err())
+	}
+
+	return questionIDs, nil
+}
+
+// SaveAnswer stores an answer and adds the awarded points to the participant's score
+func (r *pgSessionRepository) SaveAnswer(ctx context.Context, a *session.Answer) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO answers (id, participant_id, question_id, option_id, is_correct, response_time_ms, points_awarded)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING answered_at
+	`, a.ID, a.ParticipantID, a.QuestionID, a.OptionID, a.IsCorrect, a.ResponseTimeMs, a.PointsAwarded).Scan(&a.AnsweredAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return session.ErrDuplicateAnswer
+		}
+		return fmt.Errorf("failed to insert answer: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE participants SET score = score + $1 WHERE id = $2
+	`, a.PointsAwarded, a.ParticipantID)
+	if err != nil {
+		return fmt.Errorf("failed to update participant score: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ParticipantAnswers retrieves all answers of a participant in answering order
+func (r *pgSessionRepository) ParticipantAnswers(ctx context.Context, participantID string) ([]*session.Answer, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT `+answerColumns+`
+		FROM answers
+		WHERE participant_id = $1
+		ORDER BY answered_at
+	`, participantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch answers: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAnswers(rows)
+}
+
+// SessionAnswers retrieves the answers of all session participants given since a moment
+func (r *pgSessionRepository) SessionAnswers(ctx context.Context, sessionID string, since time.Time) ([]*session.Answer, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT a.id, a.participant_id, a.question_id, a.option_id, a.is_correct,
+			a.response_time_ms, a.points_awarded, a.answered_at
+		FROM answers a
+		JOIN participants p ON p.id = a.participant_id
+		WHERE p.session_id = $1 AND a.answered_at >= $2
+		ORDER BY a.answered_at
+	`, sessionID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch session answers: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAnswers(rows)
+}
+
+// SaveFlags stores suspicious answering patterns
+func (r *pgSessionRepository) SaveFlags(ctx context.Context, flags []session.Flag) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, f := range flags {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO participant_flags (id, session_id, participant_id, question_id, kind, details)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, f.ID, f.SessionID, f.ParticipantID, nullableString(f.QuestionID), f.Kind, f.Details)
+		if err != nil {
+			return fmt.Errorf("failed to insert flag: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SessionFlags retrieves all flags raised in a game session
+func (r *pgSessionRepository) SessionFlags(ctx context.Context, sessionID string) ([]session.Flag, error) {
+	rows, err := r.conn.Query(ctx, `
+		SELECT id, session_id, participant_id, question_id, kind, details, created_at
+		FROM participant_flags
+		WHERE session_id = $1
+		ORDER BY created_at
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flags: %w", err)
+	}
+	defer rows.Close()
+
+	var flags []session.Flag
+	for rows.Next() {
+		var (
+			f          session.Flag
+			questionID *string
+		)
+		if err := rows.Scan(&f.ID, &f.SessionID, &f.ParticipantID, &questionID, &f.Kind, &f.Details, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan flag row: %w", err)
+		}
+		if questionID != nil {
+			f.QuestionID = *questionID
+		}
+		flags = append(flags, f)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error iterating through flags: %w", rows.Err())
+	}
+
+	return flags, nil
+}
+
+// Helper methods
+
+// scanSession scans a single game session row
+func scanSession(row pgx.Row) (*session.Session, error) {
+	var s session.Session
+	err := row.Scan(
+		&s.ID,
+		&s.QuizID,
+		&s.HostID,
+		&s.JoinCode,
+		&s.Status,
+		&s.CurrentQuestionIndex,
+		&s.Settings.ShuffleQuestions,
+		&s.Settings.ShuffleOptions,
+		&s.Settings.PoolDraw,
+		&s.QuestionOpenedAt,
+		&s.StartedAt,
+		&s.EndedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// scanParticipant scans a single participant row
+func scanParticipant(row pgx.Row) (*session.Participant, error) {
+	var p session.Participant
+	err := row.Scan(&p.ID, &p.SessionID, &p.UserID, &p.TelegramID, &p.Login, &p.Score, &p.Seed, &p.JoinedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// scanAnswers scans answer rows
+func scanAnswers(rows pgx.Rows) ([]*session.Answer, error) {
+	var answers []*session.Answer
+	for rows.Next() {
+		var (
+			a              session.Answer
+			optionID       *string
+			responseTimeMs *int
+		)
+		if err := rows.Scan(
+			&a.ID,
+			&a.ParticipantID,
+			&a.QuestionID,
+			&optionID,
+			&a.IsCorrect,
+			&responseTimeMs,
+			&a.PointsAwarded,
+			&a.AnsweredAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan answer row: %w", err)
+		}
+		if optionID != nil {
+			a.OptionID = *optionID
+		}
+		if responseTimeMs != nil {
+			a.ResponseTimeMs = *responseTimeMs
+		}
+		answers = append(answers, &a)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error iterating through answers: %w", rows.Err())
+	}
+
+	return answers, nil
+}
+
+// nullableString maps an empty string to SQL NULL
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
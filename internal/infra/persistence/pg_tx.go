@@ -0,0 +1,80 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// querier is what the pool and a transaction have in common
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type txKey struct{}
+
+// withTx makes the repositories called with ctx run in the transaction
+func withTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// beginTx begins a transaction, nested in the transaction of ctx as a savepoint
+// when there is one
+func beginTx(ctx context.Context, conn *pgxpool.Pool) (pgx.Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Begin(ctx)
+	}
+	return conn.Begin(ctx)
+}
+
+// inTx runs fn in a transaction carried by the context passed to it,
+// fn joins the transaction of ctx when there is one
+func inTx(ctx context.Context, conn *pgxpool.Pool, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		// Already in a transaction
+		return fn(ctx)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(withTx(ctx, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// PgTransactor runs functions in a transaction the pg repositories join
+type PgTransactor struct {
+	conn *pgxpool.Pool
+}
+
+// NewPgTransactor creates a transactor on the pool of the repositories
+func NewPgTransactor(conn *pgxpool.Pool) *PgTransactor {
+	return &PgTransactor{conn: conn}
+}
+
+// InTx implements ports.Transactor.InTx
+func (t *PgTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, t.conn, fn)
+}
+
+// querierFrom returns the transaction of ctx or the pool when there is none
+func querierFrom(ctx context.Context, conn *pgxpool.Pool) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return conn
+}
@@ -30,9 +30,9 @@ func (p *pgUserRepository) Update(ctx context.Context, userID int64, updateFn fu
 
 	user := &models.User{}
 	err = tx.QueryRow(ctx, 
-		"SELECT id, login, role_flags FROM users WHERE id = $1", 
+		"SELECT id, COALESCE(telegram_id, 0), login, role_flags FROM users WHERE id = $1", 
 		userID,
-	).Scan(&user.ID, &user.Login, &user.Role)
+	).Scan(&user.ID, &user.TelegramID, &user.Login, &user.Role)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -81,8 +81,8 @@ func (p *pgUserRepository) UpdateOrCreate(ctx context.Context, user *models.User
 		if err == pgx.ErrNoRows {
 			// User doesn't exist, create a new one
 			err = tx.QueryRow(ctx,
-				"INSERT INTO users (login, role_flags) VALUES ($1, $2) RETURNING id",
-				user.Login, user.Role,
+				"INSERT INTO users (login, role_flags, telegram_id) VALUES ($1, $2, NULLIF($3, 0)) RETURNING id",
+				user.Login, user.Role, user.TelegramID,
 			).Scan(&user.ID)
 			
 			if err != nil {
@@ -95,8 +95,8 @@ func (p *pgUserRepository) UpdateOrCreate(ctx context.Context, user *models.User
 		// User exists, update it
 		user.ID = existingID
 		_, err = tx.Exec(ctx,
-			"UPDATE users SET role_flags = $1 WHERE id = $2",
-			user.Role, user.ID,
+			"UPDATE users SET role_flags = $1, telegram_id = COALESCE(NULLIF($2, 0), telegram_id) WHERE id = $3",
+			user.Role, user.TelegramID, user.ID,
 		)
 		
 		if err != nil {
@@ -110,4 +110,22 @@ func (p *pgUserRepository) UpdateOrCreate(ctx context.Context, user *models.User
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// UserByTelegramID returns the user linked to a Telegram account
+func (p *pgUserRepository) UserByTelegramID(ctx context.Context, telegramID int64) (*models.User, error) {
+	user := &models.User{}
+	err := p.conn.QueryRow(ctx,
+		"SELECT id, telegram_id, login, role_flags FROM users WHERE telegram_id = $1",
+		telegramID,
+	).Scan(&user.ID, &user.TelegramID, &user.Login, &user.Role)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ports.UserNotFoundError{TelegramID: telegramID}
+		}
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,65 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/ports"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type pgVerificationCodeRepository struct {
+	conn *pgxpool.Pool
+}
+
+// NewPgVerificationCodeRepository creates the repository, it joins the
+// transaction of PgStorage.InTx when called with its context
+func NewPgVerificationCodeRepository(conn *pgxpool.Pool) ports.VerificationCodeRepository {
+	return &pgVerificationCodeRepository{
+		conn: conn,
+	}
+}
+
+// Save inserts a verification code
+func (r *pgVerificationCodeRepository) Save(ctx context.Context, code *models.VerificationCode) error {
+	if code.ID == uuid.Nil {
+		code.ID = uuid.New()
+	}
+
+	err := querierFrom(ctx, r.conn).QueryRow(ctx,
+		`INSERT INTO verification_codes (id, telegram_id, code, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`,
+		code.ID, code.TelegramID, code.Code, code.ExpiresAt,
+	).Scan(&code.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert verification code: %w", err)
+	}
+
+	return nil
+}
+
+// LastByTelegramID returns the latest code issued to the Telegram user
+func (r *pgVerificationCodeRepository) LastByTelegramID(ctx context.Context, telegramID int64) (*models.VerificationCode, error) {
+	code := &models.VerificationCode{}
+	err := querierFrom(ctx, r.conn).QueryRow(ctx,
+		`SELECT id, telegram_id, code, expires_at, created_at
+		FROM verification_codes
+		WHERE telegram_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1`,
+		telegramID,
+	).Scan(&code.ID, &code.TelegramID, &code.Code, &code.ExpiresAt, &code.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ports.VerificationCodeNotFoundError{TelegramID: telegramID}
+		}
+		return nil, fmt.Errorf("failed to query verification code: %w", err)
+	}
+
+	return code, nil
+}
@@ -0,0 +1,80 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/service/game"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// sessionChannelPrefix names the Redis channel of a session, the API server
+// and the bot must agree on it whatever their key prefixes are
+const sessionChannelPrefix = "game:session:"
+
+// RedisEvents shares session changes between processes through Redis pub/sub.
+// Every process listens to the changes of all sessions once and fans them out
+// to its own subscribers with a game.Hub.
+type RedisEvents struct {
+	client *redis.Client
+	hub    *game.Hub
+	log    *slog.Logger
+}
+
+// NewRedisEvents creates the events on the connection of the storage,
+// Run receives the changes published by any process
+func NewRedisEvents(storage *RedisStorage, log *slog.Logger) *RedisEvents {
+	return &RedisEvents{
+		client: storage.client,
+		hub:    game.NewHub(),
+		log:    log,
+	}
+}
+
+// Publish implements game.Events.Publish. A change that cannot be published
+// is only logged, players catch up with the next one.
+func (e *RedisEvents) Publish(ctx context.Context, gameSession *session.Session) {
+	payload, err := json.Marshal(gameSession)
+	if err != nil {
+		e.log.Error("Failed to marshal session", "session_id", gameSession.ID, "error", err)
+		return
+	}
+
+	if err := e.client.Publish(ctx, sessionChannelPrefix+gameSession.ID, payload).Err(); err != nil {
+		e.log.Error("Failed to publish session", "session_id", gameSession.ID, "error", err)
+	}
+}
+
+// Subscribe implements game.Events.Subscribe
+func (e *RedisEvents) Subscribe(sessionID string) (<-chan session.Session, func()) {
+	return e.hub.Subscribe(sessionID)
+}
+
+// Run delivers the published changes to the subscribers of this process
+// until the context is done
+func (e *RedisEvents) Run(ctx context.Context) {
+	pubsub := e.client.PSubscribe(ctx, sessionChannelPrefix+"*")
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var gameSession session.Session
+			if err := json.Unmarshal([]byte(message.Payload), &gameSession); err != nil {
+				e.log.Error("Failed to unmarshal session", "channel", message.Channel, "error", err)
+				continue
+			}
+
+			e.hub.Publish(ctx, &gameSession)
+		}
+	}
+}
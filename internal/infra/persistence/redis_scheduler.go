@@ -0,0 +1,137 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"kahoot_bsu/internal/service/fsm"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// schedulerBatch is how many due jobs are claimed at once
+const schedulerBatch = 100
+
+// RedisScheduler keeps FSM jobs in a Redis sorted set scored by their time.
+// Several bot instances may run it, a job is claimed by whoever removes it first.
+type RedisScheduler struct {
+	client       *redis.Client
+	keyPrefix    string
+	pollInterval time.Duration
+	log          *slog.Logger
+}
+
+// NewRedisScheduler creates a scheduler on the connection of the storage
+func NewRedisScheduler(storage *RedisStorage, pollInterval time.Duration, log *slog.Logger) *RedisScheduler {
+	return &RedisScheduler{
+		client:       storage.client,
+		keyPrefix:    storage.keyPrefix,
+		pollInterval: pollInterval,
+		log:          log,
+	}
+}
+
+// makeJobsKey is the sorted set of all jobs
+func (s *RedisScheduler) makeJobsKey() string {
+	return s.keyPrefix + "jobs"
+}
+
+// makeConversationKey is the set of the jobs of a conversation
+func (s *RedisScheduler) makeConversationKey(chatID, userID int64) string {
+	return fmt.Sprintf("%sjobs:%d:%d", s.keyPrefix, chatID, userID)
+}
+
+// Schedule implements fsm.Scheduler.Schedule
+func (s *RedisScheduler) Schedule(ctx context.Context, job fsm.Job) error {
+	member, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	conversationKey := s.makeConversationKey(job.ChatID, job.UserID)
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, s.makeJobsKey(), redis.Z{Score: float64(job.At.UnixMilli()), Member: member})
+		pipe.SAdd(ctx, conversationKey, member)
+		pipe.ExpireAt(ctx, conversationKey, job.At.Add(time.Hour))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule job in Redis: %w", err)
+	}
+	return nil
+}
+
+// cancelScript removes the jobs of a conversation from the sorted set and drops
+// the conversation set at once, so a job scheduled meanwhile is not left behind
+var cancelScript = redis.NewScript(`
+local members = redis.call("SMEMBERS", KEYS[2])
+for i = 1, #members, 1000 do
+	redis.call("ZREM", KEYS[1], unpack(members, i, math.min(i + 999, #members)))
+end
+redis.call("DEL", KEYS[2])
+return #members
+`)
+
+// Cancel implements fsm.Scheduler.Cancel
+func (s *RedisScheduler) Cancel(ctx context.Context, chatID, userID int64) error {
+	keys := []string{s.makeJobsKey(), s.makeConversationKey(chatID, userID)}
+	if err := cancelScript.Run(ctx, s.client, keys).Err(); err != nil {
+		return fmt.Errorf("failed to cancel jobs in Redis: %w", err)
+	}
+	return nil
+}
+
+// Run implements fsm.Scheduler.Run
+func (s *RedisScheduler) Run(ctx context.Context, handle func(ctx context.Context, job fsm.Job) error) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runDue(ctx, handle)
+		}
+	}
+}
+
+// runDue claims and handles the jobs that are due
+func (s *RedisScheduler) runDue(ctx context.Context, handle func(ctx context.Context, job fsm.Job) error) {
+	members, err := s.client.ZRangeByScore(ctx, s.makeJobsKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: schedulerBatch,
+	}).Result()
+	if err != nil {
+		s.log.Error("Failed to get due jobs from Redis", "error", err)
+		return
+	}
+
+	for _, member := range members {
+		claimed, err := s.client.ZRem(ctx, s.makeJobsKey(), member).Result()
+		if err != nil {
+			s.log.Error("Failed to claim job", "error", err)
+			continue
+		}
+		if claimed == 0 {
+			// Cancelled or claimed by another instance
+			continue
+		}
+
+		var job fsm.Job
+		if err := json.Unmarshal([]byte(member), &job); err != nil {
+			s.log.Error("Failed to unmarshal job", "job", member, "error", err)
+			continue
+		}
+		s.client.SRem(ctx, s.makeConversationKey(job.ChatID, job.UserID), member)
+
+		if err := handle(ctx, job); err != nil {
+			s.log.Error("Failed to run job", "chat_id", job.ChatID, "user_id", job.UserID, "kind", job.Kind, "error", err)
+		}
+	}
+}
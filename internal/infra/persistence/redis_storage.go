@@ -65,8 +65,9 @@ func generateKey(chatID, userID int64) string {
 
 // Get implements Storage.Get
 func (s *MemoryStorage) Get(ctx context.Context, chatID int64, userID int64) (models.State, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	// Reads refresh the last access time, they need the write lock too
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	k := generateKey(chatID, userID)
 	s.lastAccess[k] = time.Now()
@@ -84,6 +85,21 @@ func (s *MemoryStorage) Set(ctx context.Context, chatID int64, userID int64, sta
 	return nil
 }
 
+// CompareAndSet implements Storage.CompareAndSet
+func (s *MemoryStorage) CompareAndSet(ctx context.Context, chatID int64, userID int64, from, to models.State) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := generateKey(chatID, userID)
+	s.lastAccess[k] = time.Now()
+	if s.states[k] != from {
+		return false, nil
+	}
+
+	s.states[k] = to
+	return true, nil
+}
+
 // Delete implements Storage.Delete
 func (s *MemoryStorage) Delete(ctx context.Context, chatID int64, userID int64) error {
 	s.mu.Lock()
@@ -97,8 +113,8 @@ func (s *MemoryStorage) Delete(ctx context.Context, chatID int64, userID int64)
 
 // GetData implements Storage.GetData
 func (s *MemoryStorage) GetData(ctx context.Context, chatID int64, userID int64, key string) (interface{}, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	k := generateKey(chatID, userID)
 	s.lastAccess[k] = time.Now()
@@ -205,6 +221,28 @@ func (s *RedisStorage) Set(ctx context.Context, chatID int64, userID int64, stat
 	return nil
 }
 
+// compareAndSetScript sets the state if it is ARGV[1], a missing key is the default state
+var compareAndSetScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1]) or ""
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+// CompareAndSet implements Storage.CompareAndSet
+func (s *RedisStorage) CompareAndSet(ctx context.Context, chatID int64, userID int64, from, to models.State) (bool, error) {
+	key := s.makeStateKey(chatID, userID)
+	set, err := compareAndSetScript.Run(ctx, s.client, []string{key},
+		string(from), string(to), s.defaultExpiry.Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to compare and set state in Redis: %w", err)
+	}
+	return set == 1, nil
+}
+
 // Delete implements Storage.Delete
 func (s *RedisStorage) Delete(ctx context.Context, chatID int64, userID int64) error {
 	key := s.makeStateKey(chatID, userID)
@@ -0,0 +1,33 @@
+package infra
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestMemoryStorageConcurrentReads runs the reads of the dispatcher workers at once,
+// run it with -race
+func TestMemoryStorageConcurrentReads(t *testing.T) {
+	storage := NewMemoryStorage(time.Hour)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				userID := int64(i*100 + j)
+				if _, err := storage.Get(ctx, 1, userID); err != nil {
+					t.Error(err)
+				}
+				if _, err := storage.GetData(ctx, 1, userID, "key"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
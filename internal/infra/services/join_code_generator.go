@@ -0,0 +1,35 @@
+package services
+
+import (
+	"crypto/rand"
+	"kahoot_bsu/internal/ports"
+)
+
+// joinCodeGenerator generates codes students type to join a game session
+type joinCodeGenerator struct {
+	lenght int
+}
+
+func NewJoinCodeGenerator(lenght int) ports.JoinCodeGenerator {
+	return &joinCodeGenerator{
+		lenght: lenght,
+	}
+}
+
+func (g *joinCodeGenerator) Generate() (string, error) {
+	// No 0/O and 1/I, they are easy to confuse on a projector
+	const joinCodeChars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	buffer := make([]byte, g.lenght)
+	_, err := rand.Read(buffer)
+	if err != nil {
+		return "", err
+	}
+
+	joinCodeCharsLength := len(joinCodeChars)
+	for i := range g.lenght {
+		buffer[i] = joinCodeChars[int(buffer[i])%joinCodeCharsLength]
+	}
+
+	return string(buffer), nil
+}
@@ -0,0 +1,95 @@
+package kahoot
+
+import (
+	"errors"
+	"kahoot_bsu/internal/auth"
+	"kahoot_bsu/internal/ports"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// initDataScheme prefixes the Mini App initData in the Authorization header
+const initDataScheme = "tma "
+
+// TelegramAuth authenticates Mini App requests by the initData Telegram signs
+// with the bot token. The authenticated user is stored in the request context
+// under "user", its ID under "userID".
+type TelegramAuth struct {
+	userRepo ports.UserRepository
+	botToken string
+	maxAge   time.Duration
+}
+
+// NewTelegramAuth creates a new TelegramAuth instance,
+// initData older than maxAge is rejected
+func NewTelegramAuth(userRepo ports.UserRepository, botToken string, maxAge time.Duration) *TelegramAuth {
+	return &TelegramAuth{
+		userRepo: userRepo,
+		botToken: botToken,
+		maxAge:   maxAge,
+	}
+}
+
+// Required rejects requests without a registered Telegram user
+func (a *TelegramAuth) Required() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if status, err := a.authenticate(c); err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Optional authenticates the user if initData is sent, e.g. for players
+// who may join a game without an account
+func (a *TelegramAuth) Optional() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+
+		if status, err := a.authenticate(c); err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticate puts the user of the request into the context
+// or returns the HTTP status and reason to reject it
+func (a *TelegramAuth) authenticate(c *gin.Context) (int, error) {
+	header := c.GetHeader("Authorization")
+	if !strings.HasPrefix(header, initDataScheme) {
+		return http.StatusUnauthorized, auth.ErrInitDataMissing
+	}
+
+	webAppUser, err := auth.ValidateWebAppData(strings.TrimPrefix(header, initDataScheme), a.botToken, a.maxAge, time.Now())
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+
+	user, err := a.userRepo.UserByTelegramID(c.Request.Context(), webAppUser.ID)
+	if err != nil {
+		var userNotFoundErr ports.UserNotFoundError
+		if errors.As(err, &userNotFoundErr) {
+			return http.StatusForbidden, errors.New("register in the bot with /register first")
+		}
+		return http.StatusInternalServerError, errors.New("failed to fetch user")
+	}
+
+	if auth.New(user).IsBlocked() {
+		return http.StatusForbidden, errors.New("user is blocked")
+	}
+
+	c.Set("user", user)
+	c.Set("userID", user.ID)
+	return http.StatusOK, nil
+}
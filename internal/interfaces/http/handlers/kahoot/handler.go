@@ -3,9 +3,13 @@ package kahoot
 import (
 	"context"
 	"errors"
+	"kahoot_bsu/internal/auth"
+	"kahoot_bsu/internal/domain/models"
 	"kahoot_bsu/internal/domain/models/question"
 	"kahoot_bsu/internal/domain/models/quiz"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,29 +20,36 @@ import (
 type Handlers struct {
 	quizRepo     quiz.Repository
 	questionRepo question.Repository
+	poolRepo     quiz.PoolRepository
 }
 
 // NewHandlers creates a new Handlers instance
-func NewHandlers(quizRepo quiz.Repository, questionRepo question.Repository) *Handlers {
+func NewHandlers(quizRepo quiz.Repository, questionRepo question.Repository, poolRepo quiz.PoolRepository) *Handlers {
 	return &Handlers{
 		quizRepo:     quizRepo,
 		questionRepo: questionRepo,
+		poolRepo:     poolRepo,
 	}
 }
 
 
-// GetQuizzes handles GET /api/quizzes
+// GetUserQuizzes handles GET /api/quizzes
 func (h *Handlers) GetUserQuizzes(c *gin.Context) {
-	// ctx := c.Request.Context()
-	// userID := c.GetString("userID")
+	ctx := c.Request.Context()
 
-	// quizzes, err := h.quizRepo.UserQuizzes(ctx, userID)
-	// if err != nil {
-	// 	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quizzes"})
-	// 	return
-	// }
-	
-	// c.JSON(http.StatusOK, quizzes)
+	userID := c.GetInt64("userID")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	quizzes, err := h.quizRepo.UserQuizzes(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quizzes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quizzes)
 }
 
 // CreateQuiz handles POST /api/quizzes
@@ -51,17 +62,35 @@ func (h *Handlers) CreateQuiz(c *gin.Context) {
 		return
 	}
 	
-	// Generate a new UUID if not provided
-	if quizData.ID == "" {
-		quizData.ID = uuid.NewString()
+	// IDs are always generated, a posted ID could name the quiz of another user
+	quizData.ID = uuid.NewString()
+
+	// Embedded questions follow the rules of AddQuizQuestion
+	for i := range quizData.Questions {
+		if err := quizData.Questions[i].Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := sanitizeQuestion(&quizData.Questions[i]); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		quizData.Questions[i].ID = uuid.NewString()
+		for j := range quizData.Questions[i].Options {
+			quizData.Questions[i].Options[j].ID = uuid.NewString()
+		}
 	}
 	
-	// Set user UUID from auth context
-	userUUID := c.GetString("userUUID")
-	if userUUID == "" {
-		userUUID = "anonymous" // Fallback
+	// Set the owner from auth context
+	value, _ := c.Get("user")
+	user, ok := value.(*models.User)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
 	}
-	quizData.UserID = userUUID
+	quizData.UserID = strconv.FormatInt(user.ID, 10)
+	quizData.CreatedBy = user.Login
 	
 	// Set timestamps
 	now := time.Now()
@@ -78,24 +107,25 @@ func (h *Handlers) CreateQuiz(c *gin.Context) {
 
 // GetQuiz handles GET /api/quizzes/:id
 func (h *Handlers) GetQuiz(c *gin.Context) {
-	ctx := c.Request.Context()
-	
 	quizUUID := c.Param("id")
 	if quizUUID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing quiz ID"})
 		return
 	}
 	
-	quizData, err := h.quizRepo.Quiz(ctx, quizUUID)
-	if err != nil {
-		var quizNotFoundErr quiz.QuizNotFoundError
-		if errors.As(err, &quizNotFoundErr) {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quiz"})
-		}
+	quizData, ok := h.accessQuiz(c, quizUUID, false)
+	if !ok {
 		return
 	}
+
+	// Readers of a public quiz do not get its answer key
+	editable := canEdit(c, quizData)
+	for i := range quizData.Questions {
+		renderQuestion(&quizData.Questions[i])
+		if !editable {
+			hideCorrectOptions(&quizData.Questions[i])
+		}
+	}
 	
 	c.JSON(http.StatusOK, quizData)
 }
@@ -110,6 +140,10 @@ func (h *Handlers) UpdateQuiz(c *gin.Context) {
 		return
 	}
 	
+	if _, ok := h.accessQuiz(c, quizUUID, true); !ok {
+		return
+	}
+
 	var updatedQuiz quiz.Quiz
 	if err := c.ShouldBindJSON(&updatedQuiz); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -156,6 +190,10 @@ func (h *Handlers) DeleteQuiz(c *gin.Context) {
 		return
 	}
 	
+	if _, ok := h.accessQuiz(c, quizUUID, true); !ok {
+		return
+	}
+
 	err := h.quizRepo.Delete(ctx, quizUUID)
 	if err != nil {
 		var quizNotFoundErr quiz.QuizNotFoundError
@@ -180,15 +218,8 @@ func (h *Handlers) GetQuizQuestions(c *gin.Context) {
 		return
 	}
 	
-	// Verify quiz exists
-	_, err := h.quizRepo.Quiz(ctx, quizUUID)
-	if err != nil {
-		var quizNotFoundErr quiz.QuizNotFoundError
-		if errors.As(err, &quizNotFoundErr) {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify quiz"})
-		}
+	quizData, ok := h.accessQuiz(c, quizUUID, false)
+	if !ok {
 		return
 	}
 	
@@ -197,6 +228,14 @@ func (h *Handlers) GetQuizQuestions(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions"})
 		return
 	}
+
+	editable := canEdit(c, quizData)
+	for _, q := range questions {
+		renderQuestion(q)
+		if !editable {
+			hideCorrectOptions(q)
+		}
+	}
 	
 	c.JSON(http.StatusOK, questions)
 }
@@ -211,15 +250,7 @@ func (h *Handlers) AddQuizQuestion(c *gin.Context) {
 		return
 	}
 	
-	// Verify quiz exists
-	_, err := h.quizRepo.Quiz(ctx, quizUUID)
-	if err != nil {
-		var quizNotFoundErr quiz.QuizNotFoundError
-		if errors.As(err, &quizNotFoundErr) {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify quiz"})
-		}
+	if _, ok := h.accessQuiz(c, quizUUID, true); !ok {
 		return
 	}
 	
@@ -228,7 +259,26 @@ func (h *Handlers) AddQuizQuestion(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+
+	if err := questionData.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := sanitizeQuestion(&questionData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	
+	if err := h.checkPool(ctx, quizUUID, questionData.PoolID); err != nil {
+		if errors.Is(err, quiz.ErrForeignPool) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify pool"})
+		}
+		return
+	}
+
 	// Generate a new UUID for the question
 	questionData.ID = uuid.NewString()
 	questionData.QuizID = quizUUID
@@ -245,6 +295,8 @@ func (h *Handlers) AddQuizQuestion(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create question"})
 		return
 	}
+
+	renderQuestion(&questionData)
 	
 	c.JSON(http.StatusCreated, questionData)
 }
@@ -259,19 +311,41 @@ func (h *Handlers) UpdateQuestion(c *gin.Context) {
 		return
 	}
 	
+	if !h.accessQuestion(c, questionUUID) {
+		return
+	}
+
 	var updatedQuestion question.Question
 	if err := c.ShouldBindJSON(&updatedQuestion); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+
+	if err := updatedQuestion.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := sanitizeQuestion(&updatedQuestion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	
 	// Ensure we use the UUID from the URL
 	updatedQuestion.ID = questionUUID
 	
 	err := h.questionRepo.Update(ctx, questionUUID, func(innerCtx context.Context, q *question.Question) error {
+		if err := h.checkPool(innerCtx, q.QuizID, updatedQuestion.PoolID); err != nil {
+			return err
+		}
+
 		q.Text = updatedQuestion.Text
 		q.TimeLimit = updatedQuestion.TimeLimit
 		q.Points = updatedQuestion.Points
+		q.PointsMode = updatedQuestion.PointsMode
+		q.IsBonus = updatedQuestion.IsBonus
+		q.Difficulty = updatedQuestion.Difficulty
+		q.PoolID = updatedQuestion.PoolID
 		q.Options = updatedQuestion.Options
 		
 		return nil
@@ -281,6 +355,8 @@ func (h *Handlers) UpdateQuestion(c *gin.Context) {
 		var questionNotFoundErr question.QuestionNotFoundError
 		if errors.As(err, &questionNotFoundErr) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if errors.Is(err, quiz.ErrForeignPool) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": quiz.ErrForeignPool.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update question"})
 		}
@@ -293,6 +369,8 @@ func (h *Handlers) UpdateQuestion(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated question"})
 		return
 	}
+
+	renderQuestion(updatedData)
 	
 	c.JSON(http.StatusOK, updatedData)
 }
@@ -307,6 +385,10 @@ func (h *Handlers) DeleteQuestion(c *gin.Context) {
 		return
 	}
 	
+	if !h.accessQuestion(c, questionUUID) {
+		return
+	}
+
 	err := h.questionRepo.Delete(ctx, questionUUID)
 	if err != nil {
 		var questionNotFoundErr question.QuestionNotFoundError
@@ -319,4 +401,143 @@ func (h *Handlers) DeleteQuestion(c *gin.Context) {
 	}
 	
 	c.Status(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// GetQuizPools handles GET /api/quizzes/:id/pools
+func (h *Handlers) GetQuizPools(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	quizUUID := c.Param("id")
+	if quizUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing quiz ID"})
+		return
+	}
+
+	if _, ok := h.accessQuiz(c, quizUUID, false); !ok {
+		return
+	}
+
+	pools, err := h.poolRepo.QuizPools(ctx, quizUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pools"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pools)
+}
+
+// UpdateQuizPools handles PUT /api/quizzes/:id/pools
+func (h *Handlers) UpdateQuizPools(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	quizUUID := c.Param("id")
+	if quizUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing quiz ID"})
+		return
+	}
+
+	if _, ok := h.accessQuiz(c, quizUUID, true); !ok {
+		return
+	}
+
+	var pools []quiz.Pool
+	if err := c.ShouldBindJSON(&pools); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range pools {
+		if err := pools[i].Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if pools[i].ID == "" {
+			pools[i].ID = uuid.NewString()
+		}
+	}
+
+	if err := h.poolRepo.SavePools(ctx, quizUUID, pools); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pools"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pools)
+}
+
+// accessQuiz loads the quiz if the authenticated user may read it or, with write,
+// change it: owners and admins may do both, anyone may read a public quiz.
+// It writes the error response otherwise.
+func (h *Handlers) accessQuiz(c *gin.Context, quizUUID string, write bool) (*quiz.Quiz, bool) {
+	if _, ok := c.Get("user"); !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	quizData, err := h.quizRepo.Quiz(c.Request.Context(), quizUUID)
+	if err != nil {
+		var quizNotFoundErr quiz.QuizNotFoundError
+		if errors.As(err, &quizNotFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify quiz"})
+		}
+		return nil, false
+	}
+
+	if canEdit(c, quizData) {
+		return quizData, true
+	}
+	if !write && quizData.IsPublic {
+		return quizData, true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "Access to the quiz is denied"})
+	return nil, false
+}
+
+// canEdit reports whether the authenticated user owns the quiz or is an admin
+func canEdit(c *gin.Context, quizData *quiz.Quiz) bool {
+	value, _ := c.Get("user")
+	user, ok := value.(*models.User)
+	if !ok {
+		return false
+	}
+
+	return quizData.UserID == strconv.FormatInt(user.ID, 10) || auth.New(user).IsAdmin()
+}
+
+// accessQuestion reports whether the authenticated user may change the question,
+// that is change its quiz. It writes the error response otherwise.
+func (h *Handlers) accessQuestion(c *gin.Context, questionUUID string) bool {
+	q, err := h.questionRepo.Question(c.Request.Context(), questionUUID)
+	if err != nil {
+		var questionNotFoundErr question.QuestionNotFoundError
+		if errors.As(err, &questionNotFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify question"})
+		}
+		return false
+	}
+
+	_, ok := h.accessQuiz(c, q.QuizID, true)
+	return ok
+}
+
+// checkPool returns quiz.ErrForeignPool unless the pool is empty or belongs to the quiz
+func (h *Handlers) checkPool(ctx context.Context, quizUUID, poolID string) error {
+	if poolID == "" {
+		return nil
+	}
+
+	pools, err := h.poolRepo.QuizPools(ctx, quizUUID)
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(pools, func(pool quiz.Pool) bool { return pool.ID == poolID }) {
+		return quiz.ErrForeignPool
+	}
+	return nil
+}
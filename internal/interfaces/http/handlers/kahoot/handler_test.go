@@ -0,0 +1,243 @@
+package kahoot
+
+import (
+	"context"
+	"kahoot_bsu/internal/auth"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryQuizzes is a quiz.Repository over a map, only reads are supported
+type memoryQuizzes struct {
+	quiz.Repository
+	quizzes map[string]*quiz.Quiz
+}
+
+func (r memoryQuizzes) Quiz(_ context.Context, uuid string) (*quiz.Quiz, error) {
+	q, ok := r.quizzes[uuid]
+	if !ok {
+		return nil, quiz.QuizNotFoundError{ID: uuid}
+	}
+	return q, nil
+}
+
+func (r memoryQuizzes) UpdateOrCreate(_ context.Context, q *quiz.Quiz) error {
+	r.quizzes[q.ID] = q
+	return nil
+}
+
+// memoryQuestions is a question.Repository over a map, only reads are supported
+type memoryQuestions struct {
+	question.Repository
+	questions map[string]*question.Question
+}
+
+func (r memoryQuestions) Question(_ context.Context, uuid string) (*question.Question, error) {
+	q, ok := r.questions[uuid]
+	if !ok {
+		return nil, question.QuestionNotFoundError{UUID: uuid}
+	}
+	return q, nil
+}
+
+func TestAccessQuiz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandlers(
+		memoryQuizzes{quizzes: map[string]*quiz.Quiz{
+			"private": {ID: "private", UserID: "1"},
+			"public":  {ID: "public", UserID: "1", IsPublic: true},
+		}},
+		memoryQuestions{questions: map[string]*question.Question{
+			"q-private": {ID: "q-private", QuizID: "private"},
+			"q-public":  {ID: "q-public", QuizID: "public"},
+		}},
+		nil,
+	)
+
+	owner := &models.User{ID: 1, Role: int64(auth.RoleTeacher)}
+	stranger := &models.User{ID: 2, Role: int64(auth.RoleTeacher)}
+	admin := &models.User{ID: 3, Role: int64(auth.RoleAdmin)}
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		quizUUID   string
+		write      bool
+		wantStatus int
+	}{
+		{name: "owner reads private", user: owner, quizUUID: "private", wantStatus: http.StatusOK},
+		{name: "owner changes private", user: owner, quizUUID: "private", write: true, wantStatus: http.StatusOK},
+		{name: "admin changes private", user: admin, quizUUID: "private", write: true, wantStatus: http.StatusOK},
+		{name: "stranger reads private", user: stranger, quizUUID: "private", wantStatus: http.StatusForbidden},
+		{name: "stranger reads public", user: stranger, quizUUID: "public", wantStatus: http.StatusOK},
+		{name: "stranger changes public", user: stranger, quizUUID: "public", write: true, wantStatus: http.StatusForbidden},
+		{name: "unknown quiz", user: owner, quizUUID: "missing", wantStatus: http.StatusNotFound},
+		{name: "anonymous", quizUUID: "public", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.user != nil {
+				c.Set("user", tt.user)
+			}
+
+			_, ok := h.accessQuiz(c, tt.quizUUID, tt.write)
+			if ok != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("accessQuiz() = %v, want %v", ok, tt.wantStatus == http.StatusOK)
+			}
+			if !ok && w.Code != tt.wantStatus {
+				t.Errorf("accessQuiz() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	questionTests := []struct {
+		name         string
+		user         *models.User
+		questionUUID string
+		wantStatus   int
+	}{
+		{name: "owner", user: owner, questionUUID: "q-private", wantStatus: http.StatusOK},
+		{name: "admin", user: admin, questionUUID: "q-private", wantStatus: http.StatusOK},
+		{name: "stranger on public quiz", user: stranger, questionUUID: "q-public", wantStatus: http.StatusForbidden},
+		{name: "unknown question", user: owner, questionUUID: "missing", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range questionTests {
+		t.Run("question "+tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Set("user", tt.user)
+
+			ok := h.accessQuestion(c, tt.questionUUID)
+			if ok != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("accessQuestion() = %v, want %v", ok, tt.wantStatus == http.StatusOK)
+			}
+			if !ok && w.Code != tt.wantStatus {
+				t.Errorf("accessQuestion() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCreateQuizChecksQuestions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "valid markup",
+			body:       `{"title": "Math", "questions": [{"text": "Solve $x^2 = 4$", "options": [{"text": "**2**"}]}]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "unclosed formula",
+			body:       `{"title": "Math", "questions": [{"text": "Solve $x^2 = 4", "options": []}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "forbidden command in option",
+			body:       `{"title": "Math", "questions": [{"text": "Pick", "options": [{"text": "$\\href{http://x}{y}$"}]}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid points mode",
+			body:       `{"title": "Math", "questions": [{"text": "Pick", "points_mode": "triple"}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlers(memoryQuizzes{quizzes: map[string]*quiz.Quiz{}}, nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/quizzes", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("user", &models.User{ID: 1, Role: int64(auth.RoleTeacher)})
+
+			h.CreateQuiz(c)
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateQuiz() status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateQuizIgnoresPostedID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	victim := &quiz.Quiz{ID: "victim", UserID: "1", Title: "Mine"}
+	quizzes := memoryQuizzes{quizzes: map[string]*quiz.Quiz{"victim": victim}}
+	h := NewHandlers(quizzes, nil, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/quizzes", strings.NewReader(`{"id": "victim", "title": "Taken"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", &models.User{ID: 2, Role: int64(auth.RoleTeacher)})
+
+	h.CreateQuiz(c)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateQuiz() status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if got := quizzes.quizzes["victim"]; got != victim || got.Title != "Mine" {
+		t.Errorf("CreateQuiz() replaced the quiz of another user: %+v", got)
+	}
+}
+
+func TestGetQuizHidesAnswerKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newQuiz := func() *quiz.Quiz {
+		return &quiz.Quiz{ID: "public", UserID: "1", IsPublic: true, Questions: []question.Question{
+			{ID: "q", Text: "Pick", Options: []question.Option{{Text: "a", IsCorrect: true}, {Text: "b"}}},
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		user        *models.User
+		wantCorrect bool
+	}{
+		{name: "owner", user: &models.User{ID: 1, Role: int64(auth.RoleTeacher)}, wantCorrect: true},
+		{name: "admin", user: &models.User{ID: 3, Role: int64(auth.RoleAdmin)}, wantCorrect: true},
+		{name: "reader", user: &models.User{ID: 2, Role: int64(auth.RoleUser)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlers(memoryQuizzes{quizzes: map[string]*quiz.Quiz{"public": newQuiz()}}, nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/quizzes/public", nil)
+			c.Params = gin.Params{{Key: "id", Value: "public"}}
+			c.Set("user", tt.user)
+
+			h.GetQuiz(c)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GetQuiz() status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := strings.Contains(w.Body.String(), `"is_correct":true`); got != tt.wantCorrect {
+				t.Errorf("GetQuiz() answer key shown = %v, want %v", got, tt.wantCorrect)
+			}
+		})
+	}
+}
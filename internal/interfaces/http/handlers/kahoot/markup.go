@@ -0,0 +1,39 @@
+package kahoot
+
+import (
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/service/markup"
+)
+
+// sanitizeQuestion validates and normalizes the markup of a question and its options
+func sanitizeQuestion(q *question.Question) error {
+	text, err := markup.Sanitize(q.Text)
+	if err != nil {
+		return err
+	}
+	q.Text = text
+
+	for i := range q.Options {
+		optionText, err := markup.Sanitize(q.Options[i].Text)
+		if err != nil {
+			return err
+		}
+		q.Options[i].Text = optionText
+	}
+
+	return nil
+}
+
+// renderQuestion fills the HTML variants of a question and its options.
+// Text that predates the markup dialect is left without an HTML variant.
+func renderQuestion(q *question.Question) {
+	if textHTML, err := markup.RenderHTML(q.Text); err == nil {
+		q.TextHTML = textHTML
+	}
+
+	for i := range q.Options {
+		if optionHTML, err := markup.RenderHTML(q.Options[i].Text); err == nil {
+			q.Options[i].TextHTML = optionHTML
+		}
+	}
+}
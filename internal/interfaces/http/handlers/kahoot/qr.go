@@ -0,0 +1,163 @@
+package kahoot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/ports"
+	"kahoot_bsu/internal/service/game"
+	"kahoot_bsu/pkg/qr"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QR code image sizes in pixels
+const (
+	defaultQRSize = 512
+	minQRSize     = 128
+	maxQRSize     = 2048
+)
+
+// Targets of join QR codes
+const (
+	QRTargetTelegram = "telegram"
+	QRTargetWeb      = "web"
+)
+
+// sendQRTimeout bounds sending the join QR code to the host
+const sendQRTimeout = 10 * time.Second
+
+// JoinQR configures the QR codes players join sessions with
+type JoinQR struct {
+	Messenger   ports.Messenger // sends hosts the QR code of new sessions, nil disables it
+	BotUsername string          // deep links through the bot need it
+	PublicURL   string          // base of web join URLs, the request host by default
+}
+
+// GetSessionQR handles GET /api/sessions/:session_id/qr?target=telegram|web&format=png|svg&size=512
+func (h *SessionHandlers) GetSessionQR(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionUUID := c.Param("session_id")
+	if sessionUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing session ID"})
+		return
+	}
+
+	size := defaultQRSize
+	if value := c.Query("size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < minQRSize || parsed > maxQRSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Size must be between %d and %d", minQRSize, maxQRSize)})
+			return
+		}
+		size = parsed
+	}
+
+	gameSession, err := h.sessionRepo.Session(ctx, sessionUUID)
+	if err != nil {
+		writeSessionError(c, err, "Failed to fetch session")
+		return
+	}
+
+	// Players join through the bot unless it is unknown
+	target := c.Query("target")
+	if target == "" {
+		target = QRTargetTelegram
+		if h.joinQR.BotUsername == "" {
+			target = QRTargetWeb
+		}
+	}
+
+	var link string
+	switch target {
+	case QRTargetTelegram:
+		if h.joinQR.BotUsername == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bot username is not configured"})
+			return
+		}
+		link = game.DeepLink(h.joinQR.BotUsername, gameSession.JoinCode)
+	case QRTargetWeb:
+		link = game.WebJoinURL(h.publicURL(c), gameSession.JoinCode)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target must be telegram or web"})
+		return
+	}
+
+	switch c.DefaultQuery("format", "png") {
+	case "png":
+		image, err := qr.PNG(link, size)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", image)
+	case "svg":
+		image, err := qr.SVG(link)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml", image)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be png or svg"})
+	}
+}
+
+// sendJoinQR sends the join QR code of a new session to its host in Telegram
+func (h *SessionHandlers) sendJoinQR(c *gin.Context, gameSession *session.Session) {
+	if h.joinQR.Messenger == nil || h.joinQR.BotUsername == "" {
+		return
+	}
+
+	value, _ := c.Get("user")
+	user, ok := value.(*models.User)
+	if !ok || user.TelegramID == 0 {
+		return
+	}
+
+	link := game.DeepLink(h.joinQR.BotUsername, gameSession.JoinCode)
+	caption := fmt.Sprintf(
+		"Код для входа: <code>%s</code>\n%s\n\nСкан QR-кода открывает бота и сразу добавляет в игру.",
+		html.EscapeString(gameSession.JoinCode),
+		html.EscapeString(link),
+	)
+
+	// The host gets the session in the response without waiting for Telegram
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), sendQRTimeout)
+		defer cancel()
+
+		image, err := qr.PNG(link, defaultQRSize)
+		if err != nil {
+			h.log.Error("Failed to generate join QR code", "session_id", gameSession.ID, "error", err)
+			return
+		}
+
+		if err := h.joinQR.Messenger.SendPhoto(ctx, user.TelegramID, image, caption); err != nil {
+			h.log.Error("Failed to send join QR code", "session_id", gameSession.ID, "error", err)
+		}
+	}()
+}
+
+// publicURL is the base URL of the web app as seen by players
+func (h *SessionHandlers) publicURL(c *gin.Context) string {
+	if h.joinQR.PublicURL != "" {
+		return h.joinQR.PublicURL
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	return scheme + "://" + c.Request.Host + "/"
+}
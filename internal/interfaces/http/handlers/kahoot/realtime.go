@@ -0,0 +1,220 @@
+package kahoot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/service/game"
+	"kahoot_bsu/pkg/ratelimit"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// Answers a single player connection may submit, same as the HTTP endpoint
+const (
+	socketAnswerRate  = 2
+	socketAnswerBurst = 5
+)
+
+// Message types of the real-time protocol
+const (
+	MessageQuestion = "question" // server: a question is open
+	MessagePaused   = "paused"   // server: the host paused the game
+	MessageFinished = "finished" // server: the game is over
+	MessageAnswer   = "answer"   // client: answer to the open question
+	MessageAck      = "ack"      // server: the answer was accepted
+	MessageError    = "error"    // server: the answer or message was rejected
+)
+
+// SocketMessage is a message of the real-time protocol in either direction.
+// Seq is chosen by the client and echoed in the ack or error of its answer.
+type SocketMessage struct {
+	Type          string             `json:"type"`
+	Seq           int                `json:"seq,omitempty"`
+	QuestionIndex int                `json:"question_index"`
+	Question      *question.Question `json:"question,omitempty"`
+	QuestionID    string             `json:"question_id,omitempty"`
+	OptionID      string             `json:"option_id,omitempty"`
+	Answer        *session.Answer    `json:"answer,omitempty"`
+	Error         string             `json:"error,omitempty"`
+}
+
+// playerSocket is a single player connection
+type playerSocket struct {
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+	questions []*question.Question
+}
+
+// PlayerSocket handles GET /api/participants/:participant_id/ws.
+// The player receives every question as the host opens it and answers it
+// over the same connection.
+func (h *SessionHandlers) PlayerSocket(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	participantUUID := c.Param("participant_id")
+	if participantUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing participant ID"})
+		return
+	}
+
+	participant, err := h.sessionRepo.Participant(ctx, participantUUID)
+	if err != nil {
+		writeSessionError(c, err, "Failed to fetch participant")
+		return
+	}
+
+	questions, err := h.game.ParticipantQuestions(ctx, participantUUID)
+	if err != nil {
+		writeSessionError(c, err, "Failed to fetch questions")
+		return
+	}
+
+	for _, q := range questions {
+		renderQuestion(q)
+		hideCorrectOptions(q)
+	}
+
+	server := websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(conn *websocket.Conn) {
+			socket := &playerSocket{conn: conn, questions: questions}
+			h.servePlayer(ctx, socket, participant)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkOrigin accepts sockets of pages served by this server or the web app
+// of the join QR codes, so another site cannot play in the name of a player
+// whose participant ID it learned. Clients other than browsers send no origin.
+func (h *SessionHandlers) checkOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	if origin == nil {
+		return nil
+	}
+
+	allowed := []string{req.Host}
+	if publicURL, err := url.Parse(h.joinQR.PublicURL); err == nil && publicURL.Host != "" {
+		allowed = append(allowed, publicURL.Host)
+	}
+	if !slices.ContainsFunc(allowed, func(host string) bool { return strings.EqualFold(host, origin.Host) }) {
+		return fmt.Errorf("origin %s is not allowed", origin)
+	}
+
+	config.Origin = origin
+	return nil
+}
+
+// servePlayer pushes session changes to the player and handles their answers
+// until either side closes the connection
+func (h *SessionHandlers) servePlayer(ctx context.Context, socket *playerSocket, participant *session.Participant) {
+	defer socket.conn.Close()
+
+	events, unsubscribe := h.game.Subscribe(participant.SessionID)
+	defer unsubscribe()
+
+	// The session may have changed before the subscription
+	gameSession, err := h.sessionRepo.Session(ctx, participant.SessionID)
+	if err != nil {
+		h.log.Error("Failed to fetch session", "session_id", participant.SessionID, "error", err)
+		return
+	}
+
+	if err := socket.sendState(gameSession); err != nil {
+		return
+	}
+
+	go func() {
+		for gameSession := range events {
+			if err := socket.sendState(&gameSession); err != nil {
+				socket.conn.Close()
+				return
+			}
+		}
+	}()
+
+	limiter := ratelimit.New(socketAnswerRate, socketAnswerBurst)
+
+	for {
+		var message SocketMessage
+		if err := websocket.JSON.Receive(socket.conn, &message); err != nil {
+			return
+		}
+
+		if message.Type != MessageAnswer {
+			socket.send(SocketMessage{Type: MessageError, Seq: message.Seq, Error: "Unknown message type"})
+			continue
+		}
+
+		if !limiter.Allow() {
+			socket.send(SocketMessage{Type: MessageError, Seq: message.Seq, Error: "Too many requests"})
+			continue
+		}
+
+		answer, err := h.game.SubmitAnswer(ctx, participant.ID, message.QuestionID, message.OptionID)
+		if err != nil {
+			socket.send(SocketMessage{Type: MessageError, Seq: message.Seq, Error: h.socketError(err)})
+			continue
+		}
+
+		socket.send(SocketMessage{Type: MessageAck, Seq: message.Seq, Answer: answer})
+	}
+}
+
+// sendState tells the player what the session state means for them
+func (s *playerSocket) sendState(gameSession *session.Session) error {
+	index := gameSession.CurrentQuestionIndex
+
+	switch {
+	case gameSession.Status&session.StatusFinished != 0:
+		return s.send(SocketMessage{Type: MessageFinished, QuestionIndex: index})
+	case gameSession.Status&session.StatusPaused != 0:
+		return s.send(SocketMessage{Type: MessagePaused, QuestionIndex: index})
+	case gameSession.Status&session.StatusActive != 0 && index < len(s.questions):
+		return s.send(SocketMessage{Type: MessageQuestion, QuestionIndex: index, Question: s.questions[index]})
+	}
+
+	return nil
+}
+
+// send writes a message, the connection allows a single writer at a time
+func (s *playerSocket) send(message SocketMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return websocket.JSON.Send(s.conn, message)
+}
+
+// socketError hides internal errors from the player
+func (h *SessionHandlers) socketError(err error) string {
+	var (
+		sessionNotFoundErr     session.SessionNotFoundError
+		participantNotFoundErr session.ParticipantNotFoundError
+	)
+
+	switch {
+	case errors.As(err, &sessionNotFoundErr),
+		errors.As(err, &participantNotFoundErr),
+		errors.Is(err, game.ErrSessionFinished),
+		errors.Is(err, game.ErrQuestionClosed),
+		errors.Is(err, game.ErrUnknownQuestion),
+		errors.Is(err, game.ErrUnknownOption),
+		errors.Is(err, session.ErrDuplicateAnswer):
+		return err.Error()
+	default:
+		h.log.Error("Failed to submit answer", "error", err)
+		return "Failed to submit answer"
+	}
+}
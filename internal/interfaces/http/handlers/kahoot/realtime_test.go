@@ -0,0 +1,40 @@
+package kahoot
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	h := &SessionHandlers{joinQR: JoinQR{PublicURL: "https://quiz.example.org/app"}}
+
+	tests := []struct {
+		name    string
+		origin  string
+		wantErr bool
+	}{
+		{name: "no origin", origin: ""},
+		{name: "same host", origin: "http://api.example.org"},
+		{name: "public url", origin: "https://quiz.example.org"},
+		{name: "public url in upper case", origin: "https://QUIZ.example.org"},
+		{name: "other site", origin: "https://evil.example.com", wantErr: true},
+		{name: "public url as subdomain", origin: "https://quiz.example.org.evil.com", wantErr: true},
+		{name: "invalid origin", origin: "://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://api.example.org/api/participants/p/ws", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			err := h.checkOrigin(&websocket.Config{Version: websocket.ProtocolVersionHybi13}, req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkOrigin(%q) error = %v, want error %v", tt.origin, err, tt.wantErr)
+			}
+		})
+	}
+}
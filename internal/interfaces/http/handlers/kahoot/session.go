@@ -0,0 +1,334 @@
+package kahoot
+
+import (
+	"context"
+	"errors"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/service/game"
+	"kahoot_bsu/pkg/ratelimit"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandlers contains the HTTP handlers for game sessions
+type SessionHandlers struct {
+	quizRepo    quiz.Repository
+	sessionRepo session.Repository
+	game        *game.Service
+	joinQR      JoinQR
+	log         *slog.Logger
+}
+
+// NewSessionHandlers creates a new SessionHandlers instance
+func NewSessionHandlers(
+	quizRepo quiz.Repository,
+	sessionRepo session.Repository,
+	game *game.Service,
+	joinQR JoinQR,
+	log *slog.Logger,
+) *SessionHandlers {
+	return &SessionHandlers{
+		quizRepo:    quizRepo,
+		sessionRepo: sessionRepo,
+		game:        game,
+		joinQR:      joinQR,
+		log:         log,
+	}
+}
+
+type createSessionRequest struct {
+	Settings session.Settings `json:"settings"`
+}
+
+type joinSessionRequest struct {
+	JoinCode string `json:"join_code" binding:"required"`
+	Login    string `json:"login" binding:"required"`
+}
+
+type submitAnswerRequest struct {
+	QuestionID string `json:"question_id" binding:"required"`
+	OptionID   string `json:"option_id" binding:"required"`
+}
+
+// CreateSession handles POST /api/quizzes/:id/sessions
+func (h *SessionHandlers) CreateSession(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// The host is a registered user, sessions reference it
+	hostID := c.GetInt64("userID")
+	if hostID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	quizUUID := c.Param("id")
+	if quizUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing quiz ID"})
+		return
+	}
+
+	var request createSessionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify quiz exists and may be hosted by the user
+	quizData, err := h.quizRepo.Quiz(ctx, quizUUID)
+	if err != nil {
+		var quizNotFoundErr quiz.QuizNotFoundError
+		if errors.As(err, &quizNotFoundErr) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify quiz"})
+		}
+		return
+	}
+	if !quizData.IsPublic && quizData.UserID != strconv.FormatInt(hostID, 10) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Quiz is private"})
+		return
+	}
+
+	gameSession, err := h.game.CreateSession(ctx, quizUUID, hostID, request.Settings)
+	if err != nil {
+		writeSessionError(c, err, "Failed to create session")
+		return
+	}
+
+	h.sendJoinQR(c, gameSession)
+
+	c.JSON(http.StatusCreated, gameSession)
+}
+
+// GetSession handles GET /api/sessions/:session_id
+func (h *SessionHandlers) GetSession(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionUUID := c.Param("session_id")
+	if sessionUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing session ID"})
+		return
+	}
+
+	gameSession, err := h.sessionRepo.Session(ctx, sessionUUID)
+	if err != nil {
+		writeSessionError(c, err, "Failed to fetch session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gameSession)
+}
+
+// JoinSession handles POST /api/sessions/join
+func (h *SessionHandlers) JoinSession(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var request joinSessionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var userID *int64
+	if id := c.GetInt64("userID"); id != 0 {
+		userID = &id
+	}
+
+	participant, err := h.game.Join(ctx, request.JoinCode, userID, request.Login)
+	if err != nil {
+		writeSessionError(c, err, "Failed to join session")
+		return
+	}
+
+	c.JSON(http.StatusCreated, participant)
+}
+
+// GetParticipantQuestions handles GET /api/participants/:participant_id/questions
+func (h *SessionHandlers) GetParticipantQuestions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	participantUUID := c.Param("participant_id")
+	if participantUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing participant ID"})
+		return
+	}
+
+	questions, err := h.game.ParticipantQuestions(ctx, participantUUID)
+	if err != nil {
+		writeSessionError(c, err, "Failed to fetch questions")
+		return
+	}
+
+	for _, q := range questions {
+		renderQuestion(q)
+		hideCorrectOptions(q)
+	}
+
+	c.JSON(http.StatusOK, questions)
+}
+
+// SubmitAnswer handles POST /api/participants/:participant_id/answers
+func (h *SessionHandlers) SubmitAnswer(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	participantUUID := c.Param("participant_id")
+	if participantUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing participant ID"})
+		return
+	}
+
+	var request submitAnswerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	answer, err := h.game.SubmitAnswer(ctx, participantUUID, request.QuestionID, request.OptionID)
+	if err != nil {
+		writeSessionError(c, err, "Failed to submit answer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, answer)
+}
+
+// StartSession handles POST /api/sessions/:session_id/start
+func (h *SessionHandlers) StartSession(c *gin.Context) {
+	h.controlSession(c, h.game.Start)
+}
+
+// NextQuestion handles POST /api/sessions/:session_id/next
+func (h *SessionHandlers) NextQuestion(c *gin.Context) {
+	h.controlSession(c, h.game.NextQuestion)
+}
+
+// PauseSession handles POST /api/sessions/:session_id/pause
+func (h *SessionHandlers) PauseSession(c *gin.Context) {
+	h.controlSession(c, h.game.Pause)
+}
+
+// FinishSession handles POST /api/sessions/:session_id/finish
+func (h *SessionHandlers) FinishSession(c *gin.Context) {
+	h.controlSession(c, h.game.Finish)
+}
+
+// GetSessionReport handles GET /api/sessions/:session_id/report
+func (h *SessionHandlers) GetSessionReport(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionUUID := c.Param("session_id")
+	if sessionUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing session ID"})
+		return
+	}
+
+	if !h.checkHost(c, sessionUUID) {
+		return
+	}
+
+	report, err := h.game.Report(ctx, sessionUUID)
+	if err != nil {
+		writeSessionError(c, err, "Failed to build report")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RateLimit rejects requests beyond the limit of their client connection.
+// The key combines the client address with the participant, if any.
+func RateLimit(limiter *ratelimit.Keyed) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.ClientIP() + "|" + c.Param("participant_id")
+
+		if !limiter.Allow(key) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// controlSession applies a host action to the session from the URL
+func (h *SessionHandlers) controlSession(
+	c *gin.Context,
+	action func(ctx context.Context, sessionID string) (*session.Session, error),
+) {
+	ctx := c.Request.Context()
+
+	sessionUUID := c.Param("session_id")
+	if sessionUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing session ID"})
+		return
+	}
+
+	if !h.checkHost(c, sessionUUID) {
+		return
+	}
+
+	gameSession, err := action(ctx, sessionUUID)
+	if err != nil {
+		writeSessionError(c, err, "Failed to update session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gameSession)
+}
+
+// checkHost reports whether the authenticated user hosts the session,
+// it writes the error response otherwise
+func (h *SessionHandlers) checkHost(c *gin.Context, sessionUUID string) bool {
+	gameSession, err := h.sessionRepo.Session(c.Request.Context(), sessionUUID)
+	if err != nil {
+		writeSessionError(c, err, "Failed to fetch session")
+		return false
+	}
+
+	if gameSession.HostID != c.GetInt64("userID") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the host can manage the session"})
+		return false
+	}
+
+	return true
+}
+
+// writeSessionError maps game and session errors to HTTP responses
+func writeSessionError(c *gin.Context, err error, message string) {
+	var (
+		sessionNotFoundErr     session.SessionNotFoundError
+		participantNotFoundErr session.ParticipantNotFoundError
+		questionNotFoundErr    question.QuestionNotFoundError
+	)
+
+	switch {
+	case errors.As(err, &sessionNotFoundErr),
+		errors.As(err, &participantNotFoundErr),
+		errors.As(err, &questionNotFoundErr):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, game.ErrSessionFinished),
+		errors.Is(err, game.ErrSessionStarted),
+		errors.Is(err, game.ErrSessionNotStarted),
+		errors.Is(err, game.ErrQuestionClosed),
+		errors.Is(err, game.ErrPoolTooSmall),
+		errors.Is(err, session.ErrDuplicateAnswer):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, game.ErrUnknownQuestion),
+		errors.Is(err, game.ErrUnknownOption):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// hideCorrectOptions removes the answer key from a question sent to a player
+func hideCorrectOptions(q *question.Question) {
+	for i := range q.Options {
+		q.Options[i].IsCorrect = false
+	}
+}
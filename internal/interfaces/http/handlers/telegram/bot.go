@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"kahoot_bsu/internal/app/command"
-	"kahoot_bsu/internal/app/messages"
 	"kahoot_bsu/internal/config"
 	"kahoot_bsu/internal/domain/models"
 	"kahoot_bsu/internal/infra/clients"
@@ -34,6 +33,8 @@ type AppTelegram struct {
 	Bot    *models.Bot
 	Log    *slog.Logger
 	router *fsm.Router
+	// registerScene is entered by /register
+	registerScene *fsm.Scene
 }
 
 func NewAppTelegram() (
@@ -91,10 +92,12 @@ func NewAppTelegram() (
 	userRepo := infra.NewPgUserRepository(db)
 	otpGenerator := services.NewVerificationOTPGenerator(6)
 
-	fSMHandler := messages.NewFSMHandler(emailService, userRepo, otpGenerator)
+	codeRepo := infra.NewPgVerificationCodeRepository(db)
 
-	router.Register(fsm.StateAwaitingLogin, fSMHandler.HandleLogin)
-	router.Register(fsm.StateAwaitingOTP, fSMHandler.HandleOTP)
+	fSMHandler := NewFSMHandler(emailService, userRepo, codeRepo, otpGenerator)
+
+	registerScene := fSMHandler.RegisterScene()
+	registerScene.Register(router)
 	router.Register(fsm.StateRegistered, fSMHandler.HandleRegistered)
 
 	app = &AppTelegram{
@@ -103,6 +106,8 @@ func NewAppTelegram() (
 		Conn:   db,
 		Log:    log,
 		router: router,
+
+		registerScene: registerScene,
 	}
 
 	closeFunc := func() error {
@@ -148,11 +153,11 @@ func Start(a *AppTelegram) {
 		fsm := fsm.NewFSMContext(context.Background(), a.router.Storage, chatID, userID)
 
 		if update.Message.IsCommand() {
-			handleCommand(a, update.Message, fsm)
+			handleCommand(context.Background(), a, update.Message, fsm)
 		} else {
 
-			if err := a.router.ProcessUpdate(context.Background(), &update, a.Bot); err != nil {
-				a.Log.Error("Error processing update: %v", err)
+			if err := a.router.ProcessUpdate(context.Background(), update.Message, a.Bot, fsm); err != nil {
+				a.Log.Error("Error processing update", "error", err)
 			}
 
 		}
@@ -160,12 +165,12 @@ func Start(a *AppTelegram) {
 }
 
 type CommandInterface interface {
-	Execute(message *tgbotapi.Message)
+	Execute(ctx context.Context, message *tgbotapi.Message)
 }
 
-func handleCommand(a *AppTelegram, message *tgbotapi.Message, fsm *fsm.FSMContext) {
-	comandHandler := command.New(a.Bot, "https://af09-185-53-133-77.ngrok-free.app/")
-	registerHandler := command.NewRegisterCommand(comandHandler, fsm)
+func handleCommand(ctx context.Context, a *AppTelegram, message *tgbotapi.Message, fsm *fsm.FSMContext) {
+	comandHandler := command.New(a.Bot, "https://af09-185-53-133-77.ngrok-free.app/", a.Log)
+	registerHandler := command.NewRegisterCommand(comandHandler, fsm, a.registerScene)
 
 	commandStrategy := map[string]CommandInterface{
 		"start":    &command.StartCommand{CommandHandler: comandHandler},
@@ -178,11 +183,11 @@ func handleCommand(a *AppTelegram, message *tgbotapi.Message, fsm *fsm.FSMContex
 	_, ok := commandStrategy[message.Command()]
 
 	if !ok {
-		commandStrategy["unknown"].Execute(message)
+		commandStrategy["unknown"].Execute(ctx, message)
 		return
 	}
 
-	commandStrategy[message.Command()].Execute(message)
+	commandStrategy[message.Command()].Execute(ctx, message)
 }
 
 
@@ -3,6 +3,7 @@ package telegram
 import (
 	"context"
 	"errors"
+	"fmt"
 	"kahoot_bsu/internal/auth"
 	"kahoot_bsu/internal/domain/models"
 	"kahoot_bsu/internal/infra/services"
@@ -15,125 +16,160 @@ import (
 	fsmSrv "kahoot_bsu/internal/service/fsm"
 )
 
-const (
-	DefaultState       models.State = ""
-	StateStart         models.State = "start"
-	StateAwaitingLogin models.State = "awaiting_login"
-	StateAwaitingOTP   models.State = "awaiting_otp"
-	StateRegistered    models.State = "registered"
-)
+// otpLifetime is how long a verification code is accepted
+const otpLifetime = 30 * time.Minute
+
+// Registration data kept in the FSM between the steps
+var loginData = fsmSrv.NewData[string]("login")
 
 type fSMHandler struct {
 	emailService *services.EmailService
 	userRepo     ports.UserRepository
+	codeRepo     ports.VerificationCodeRepository
 	otpGenerator ports.VerificationCodeGenerator
 }
 
 func NewFSMHandler(
 	emailService *services.EmailService,
 	userRepo ports.UserRepository,
+	codeRepo ports.VerificationCodeRepository,
 	otpGenerator ports.VerificationCodeGenerator,
 ) *fSMHandler {
 	return &fSMHandler{
 		emailService: emailService,
 		userRepo:     userRepo,
+		codeRepo:     codeRepo,
 		otpGenerator: otpGenerator,
 	}
 }
 
-func (h *fSMHandler) HandleLogin(ctx context.Context, fsm *fsmSrv.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
-	login := message.Text
+// RegisterScene asks for the login and the code sent to its email,
+// the user is created when the code matches
+func (h *fSMHandler) RegisterScene() *fsmSrv.Scene {
+	return fsmSrv.NewScene("register", []fsmSrv.Step{
+		{
+			Name:     "login",
+			State:    fsmSrv.StateAwaitingLogin,
+			Prompt:   "🔑 Пожалуйста, введите ваш login для регистрации.",
+			Validate: h.validateLogin,
+		},
+		{
+			Name:      "otp",
+			State:     fsmSrv.StateAwaitingOTP,
+			Prompt:    "Спасибо! На ваш <a href=\"https://webmail.bsu.by/owa/#path=/mail\">email</a> был выслан проверочный код. \n Пожалуйста, введите его:",
+			ParseMode: "HTML",
+			Enter:     h.issueOTP,
+			Entered:   h.sendOTP,
+			Validate:  h.validateOTP,
+		},
+	}, h.register,
+		fsmSrv.WithDoneState(fsmSrv.StateRegistered),
+		fsmSrv.WithCancelText("Регистрация отменена. Чтобы начать заново, отправьте /register"),
+	)
+}
 
-	// Store login in FSM data
-	if err := fsm.SetData("login", login); err != nil {
-		panic(err)
+func (h *fSMHandler) validateLogin(ctx context.Context, fsm *fsmSrv.FSMContext, login string) (string, error) {
+	// The email is the login without its prefix, see EmailConfig.Prefix
+	if len(login) <= 4 || len(login) > 30 {
+		return "", fsmSrv.InputError{Message: "Некорректный login. Введите ваш login:"}
 	}
+	return login, nil
+}
 
+// issueOTP issues a verification code for the login, it runs in the transaction
+// of the transition so a failed insert leaves the user in the login step
+func (h *fSMHandler) issueOTP(ctx context.Context, fsm *fsmSrv.FSMContext) error {
 	otp, err := h.otpGenerator.Generate()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to generate verification code: %w", err)
 	}
 
-	err = fsm.SetData("code", otp)
+	code := &models.VerificationCode{
+		TelegramID: fsm.UserID(),
+		Code:       otp,
+		ExpiresAt:  time.Now().Add(otpLifetime),
+	}
+	if err := h.codeRepo.Save(ctx, code); err != nil {
+		return fmt.Errorf("failed to save verification code: %w", err)
+	}
+
+	return nil
+}
 
+// sendOTP emails the issued code once its transaction is committed,
+// a rolled back code is never sent
+func (h *fSMHandler) sendOTP(ctx context.Context, fsm *fsmSrv.FSMContext) error {
+	login, ok, err := loginData.Get(fsm)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	if !ok {
+		return errors.New("login is missing in registration data")
 	}
 
-	expiresAt := time.Now().Add(30 * time.Minute)
+	code, err := h.codeRepo.LastByTelegramID(ctx, fsm.UserID())
+	if err != nil {
+		return fmt.Errorf("failed to get verification code: %w", err)
+	}
 
-	if err := h.emailService.Send(login[4:], "Your Verification Code", otp, expiresAt); err != nil {
+	if err := h.emailService.Send(login[4:], "Your Verification Code", code.Code, code.ExpiresAt); err != nil {
 		log.Printf("Failed to sentd verification email: %v", err)
 	} else {
 		log.Printf("Verification email sent to %s", login)
 	}
 
-	// msg := tgbotapi.NewMessage(message.Chat.ID, "Спасибо. На ваш [email](https://webmail.bsu.by/owa/#path=/mail) был выслан проверочный код. \n Пожалуйста, введите его:")
-	// msg.ParseMode = "MarkdownV2"
-	msg := tgbotapi.NewMessage(message.Chat.ID, "Спасибо! На ваш <a href=\"https://webmail.bsu.by/owa/#path=/mail\">email</a> был выслан проверочный код. \n Пожалуйста, введите его:")
-	msg.ParseMode = "HTML"
-	_, err = bot.Telegram.Send(msg)
-	if err != nil {
-		panic(err)
-	}
-
-	return fsm.Set(fsmSrv.StateAwaitingOTP)
+	return nil
 }
 
-func (h *fSMHandler) HandleOTP(ctx context.Context, fsm *fsmSrv.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
-	inputOTP := message.Text
-	fsmOTP, err := fsm.GetData("code")
+// validateOTP accepts only the latest issued code before it expires
+func (h *fSMHandler) validateOTP(ctx context.Context, fsm *fsmSrv.FSMContext, inputOTP string) (string, error) {
+	code, err := h.codeRepo.LastByTelegramID(ctx, fsm.UserID())
 	if err != nil {
-		panic(err)
+		var notFound ports.VerificationCodeNotFoundError
+		if errors.As(err, &notFound) {
+			return "", fsmSrv.InputError{Message: "Код подтверждения не найден. Чтобы получить новый, отправьте /register"}
+		}
+		return "", fmt.Errorf("failed to get verification code: %w", err)
 	}
 
-	loginInterface, err := fsm.GetData("login")
-	if err != nil {
-		panic(err)
+	if time.Now().After(code.ExpiresAt) {
+		return "", fsmSrv.InputError{Message: "Код подтверждения истёк. Чтобы получить новый, отправьте /register"}
 	}
-
-	login, ok := loginInterface.(string)
-	if !ok {
-		return errors.New("invalid login data format")
+	if len(inputOTP) != 6 || inputOTP != code.Code {
+		return "", fsmSrv.InputError{Message: "Неверный проверочный код. Введите проверчный код:"}
 	}
+	return inputOTP, nil
+}
+
+// register creates the user after the code is confirmed
+func (h *fSMHandler) register(ctx context.Context, fsm *fsmSrv.FSMContext, message *tgbotapi.Message, bot *models.Bot, data map[string]string) error {
+	login := data["login"]
 
-	if len(inputOTP) != 6 || inputOTP != fsmOTP {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Неверный проверочный код. Введите проверчный код:")
-		_, err := bot.Telegram.Send(msg)
-		panic(err)
+	user := &models.User{
+		TelegramID: message.From.ID,
+		Login:      login,
+		Role:       int64(auth.RoleUser),
 	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, "Регастрация завершена! Добро пожаловать, "+login+"!" + "\nВы можете перейти к сервису прохождения викторин кликнув на /quiz")
-	_, err = bot.Telegram.Send(msg)
-	if err != nil {
-		panic(err)
+	if err := h.userRepo.UpdateOrCreate(ctx, user); err != nil {
+		return fmt.Errorf("failed to register user: %w", err)
 	}
 
-	return fsm.Set(fsmSrv.StateRegistered)
+	msg := tgbotapi.NewMessage(message.Chat.ID, "Регастрация завершена! Добро пожаловать, "+login+"!"+"\nВы можете перейти к сервису прохождения викторин кликнув на /quiz")
+	_, err := bot.Telegram.Send(msg)
+	return err
 }
 
 func (h *fSMHandler) HandleRegistered(ctx context.Context, fsm *fsmSrv.FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
-	loginInterface, err := fsm.GetData("login")
+	login, ok, err := loginData.Get(fsm)
 	if err != nil {
 		return err
 	}
-
-	login, ok := loginInterface.(string)
 	if !ok {
-		return errors.New("invalid login data format")
-	}
-
-	user := &models.User{
-		ID:    bot.Telegram.Self.ID,
-		Login: login,
-		Role:  int64(auth.RoleUser),
-	}
-
-	err = h.userRepo.UpdateOrCreate(ctx, user)
-	if err != nil {
-		panic(err)
+		return errors.New("login is missing in registration data")
 	}
 
+	// The user was created when the register scene was committed
 	msg := tgbotapi.NewMessage(message.Chat.ID, "Привет "+login+"! Вы уже зарегистрированы.")
 	_, err = bot.Telegram.Send(msg)
 	return err
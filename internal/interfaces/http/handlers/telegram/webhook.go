@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"kahoot_bsu/internal/config"
+	"net/http"
+	"net/url"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SecretTokenHeader carries the secret_token of the webhook in every update request
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// maxUpdateSize bounds the body of an update request
+const maxUpdateSize = 1 << 20
+
+// Webhook receives the updates Telegram posts to the bot. It is an http.Handler,
+// so it can be served on its own or mounted on the gin server with gin.WrapH.
+type Webhook struct {
+	secretToken string
+	updates     chan tgbotapi.Update
+}
+
+// NewWebhook creates a webhook that accepts requests carrying the secret token
+func NewWebhook(secretToken string, buffer int) *Webhook {
+	return &Webhook{
+		secretToken: secretToken,
+		updates:     make(chan tgbotapi.Update, buffer),
+	}
+}
+
+// Updates returns the channel of received updates, it is read like the long polling one
+func (w *Webhook) Updates() tgbotapi.UpdatesChannel {
+	return w.updates
+}
+
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.Header.Get(SecretTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(w.secretToken)) != 1 {
+		http.Error(rw, "invalid secret token", http.StatusUnauthorized)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxUpdateSize)).Decode(&update); err != nil {
+		http.Error(rw, "invalid update", http.StatusBadRequest)
+		return
+	}
+
+	// Telegram retries the update later if the dispatcher falls behind
+	select {
+	case w.updates <- update:
+		rw.WriteHeader(http.StatusOK)
+	case <-r.Context().Done():
+		http.Error(rw, "update queue is full", http.StatusServiceUnavailable)
+	}
+}
+
+// Close stops the updates channel, no requests may be served after it
+func (w *Webhook) Close() {
+	close(w.updates)
+}
+
+// SetWebhook tells Telegram to post the updates of the bot to the webhook URL
+// with the secret token in the SecretTokenHeader header
+func SetWebhook(bot *tgbotapi.BotAPI, cfg config.WebhookConfig) error {
+	if _, err := url.ParseRequestURI(cfg.URL); err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	params := tgbotapi.Params{}
+	params["url"] = cfg.URL
+	params.AddNonEmpty("secret_token", cfg.SecretToken)
+	params.AddNonZero("max_connections", cfg.MaxConnections)
+	params.AddBool("drop_pending_updates", cfg.DropPendingUpdates)
+	if len(cfg.AllowedUpdates) > 0 {
+		if err := params.AddInterface("allowed_updates", cfg.AllowedUpdates); err != nil {
+			return err
+		}
+	}
+
+	if _, err := bot.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,6 @@
+package ports
+
+// FormulaRenderer turns a LaTeX formula into a link to an image rendered on request
+type FormulaRenderer interface {
+	ImageURL(formula string) (string, error)
+}
@@ -0,0 +1,5 @@
+package ports
+
+type JoinCodeGenerator interface {
+	Generate() (string, error)
+}
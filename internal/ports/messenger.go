@@ -0,0 +1,8 @@
+package ports
+
+import "context"
+
+// Messenger sends messages to Telegram chats on behalf of the bot
+type Messenger interface {
+	SendPhoto(ctx context.Context, chatID int64, photo []byte, caption string) error
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "context"
+
+// Transactor runs functions in a database transaction, the repositories
+// called with the context passed to fn join it
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -2,9 +2,18 @@ package ports
 
 import (
 	"context"
+	"fmt"
 	"kahoot_bsu/internal/domain/models"
 )
 
+type UserNotFoundError struct {
+	TelegramID int64
+}
+
+func (e UserNotFoundError) Error() string {
+	return fmt.Sprintf("user with telegram ID %d not found", e.TelegramID)
+}
+
 type UserRepository interface {	
 	Update(
 		ctx context.Context, 
@@ -13,4 +22,5 @@ type UserRepository interface {
 	) error
 	
 	UpdateOrCreate(ctx context.Context, user *models.User) error
+	UserByTelegramID(ctx context.Context, telegramID int64) (*models.User, error)
 }
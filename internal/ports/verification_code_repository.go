@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"kahoot_bsu/internal/domain/models"
+)
+
+type VerificationCodeNotFoundError struct {
+	TelegramID int64
+}
+
+func (e VerificationCodeNotFoundError) Error() string {
+	return fmt.Sprintf("verification code for telegram ID %d not found", e.TelegramID)
+}
+
+type VerificationCodeRepository interface {
+	Save(ctx context.Context, code *models.VerificationCode) error
+	// LastByTelegramID returns the latest code issued to the user
+	LastByTelegramID(ctx context.Context, telegramID int64) (*models.VerificationCode, error)
+}
@@ -0,0 +1,170 @@
+package fsm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrDataVersion is returned for data stored by a newer version of the code
+var ErrDataVersion = errors.New("fsm data has an unknown version")
+
+// Codec encodes typed FSM data. Payloads must be text, every storage keeps them as strings.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// JSONCodec encodes data as JSON, it is the default codec
+var JSONCodec Codec = jsonCodec{}
+
+// Migration upgrades a payload to the next version of its key
+type Migration func(payload []byte) ([]byte, error)
+
+// envelopePrefix starts the stored form of typed data, "v<version>:<payload>"
+const envelopePrefix = "v"
+
+// Data is a typed key of FSM data. Values are stored with the version of the key,
+// older values are upgraded by the migrations when they are read.
+//
+//	var quizTitle = fsm.NewData[string]("quiz_title")
+//	title, ok, err := quizTitle.Get(fsmCtx)
+type Data[T any] struct {
+	key        string
+	version    int
+	codec      Codec
+	migrations map[int]Migration
+}
+
+// DataOption configures a Data key
+type DataOption func(*dataOptions)
+
+type dataOptions struct {
+	version    int
+	codec      Codec
+	migrations map[int]Migration
+}
+
+// WithVersion sets the version of the stored values, 1 by default
+func WithVersion(version int) DataOption {
+	return func(o *dataOptions) {
+		o.version = version
+	}
+}
+
+// WithCodec sets the codec of the values, JSONCodec by default
+func WithCodec(codec Codec) DataOption {
+	return func(o *dataOptions) {
+		o.codec = codec
+	}
+}
+
+// WithMigration upgrades the values of version from to version from+1.
+// Version 0 is the data stored before the key was typed.
+// Versions without a migration are decoded as they are.
+func WithMigration(from int, migration Migration) DataOption {
+	return func(o *dataOptions) {
+		o.migrations[from] = migration
+	}
+}
+
+// NewData creates a typed key of FSM data
+func NewData[T any](key string, opts ...DataOption) Data[T] {
+	options := dataOptions{
+		version:    1,
+		codec:      JSONCodec,
+		migrations: make(map[int]Migration),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return Data[T]{
+		key:        key,
+		version:    options.version,
+		codec:      options.codec,
+		migrations: options.migrations,
+	}
+}
+
+// Key returns the name of the key in the FSM data
+func (d Data[T]) Key() string {
+	return d.key
+}
+
+// Get returns the value of the key, ok is false if it is not set
+func (d Data[T]) Get(fsm *FSMContext) (value T, ok bool, err error) {
+	raw, err := fsm.GetData(d.key)
+	if err != nil || raw == nil {
+		return value, false, err
+	}
+
+	version, payload, err := d.unwrap(raw)
+	if err != nil {
+		return value, false, err
+	}
+
+	if version > d.version {
+		return value, false, fmt.Errorf("%w: %s is version %d, expected %d", ErrDataVersion, d.key, version, d.version)
+	}
+
+	for ; version < d.version; version++ {
+		if migrate, ok := d.migrations[version]; ok {
+			if payload, err = migrate(payload); err != nil {
+				return value, false, fmt.Errorf("failed to migrate %s from version %d: %w", d.key, version, err)
+			}
+		}
+	}
+
+	if err := d.codec.Unmarshal(payload, &value); err != nil {
+		return value, false, fmt.Errorf("failed to decode %s: %w", d.key, err)
+	}
+
+	return value, true, nil
+}
+
+// Set stores the value of the key
+func (d Data[T]) Set(fsm *FSMContext, value T) error {
+	payload, err := d.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", d.key, err)
+	}
+
+	return fsm.SetData(d.key, envelopePrefix+strconv.Itoa(d.version)+":"+string(payload))
+}
+
+// Update replaces the value of the key with the result of fn,
+// fn gets the zero value if the key is not set
+func (d Data[T]) Update(fsm *FSMContext, fn func(value T) T) error {
+	value, _, err := d.Get(fsm)
+	if err != nil {
+		return err
+	}
+
+	return d.Set(fsm, fn(value))
+}
+
+// unwrap returns the version and the payload of a stored value,
+// values stored without an envelope are version 0
+func (d Data[T]) unwrap(raw any) (int, []byte, error) {
+	if s, ok := raw.(string); ok && strings.HasPrefix(s, envelopePrefix) {
+		if version, payload, ok := strings.Cut(s[len(envelopePrefix):], ":"); ok {
+			if v, err := strconv.Atoi(version); err == nil {
+				return v, []byte(payload), nil
+			}
+		}
+	}
+
+	payload, err := d.codec.Marshal(raw)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to encode legacy %s: %w", d.key, err)
+	}
+	return 0, payload, nil
+}
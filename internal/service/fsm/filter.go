@@ -0,0 +1,106 @@
+package fsm
+
+import (
+	"context"
+	"kahoot_bsu/internal/auth"
+	"kahoot_bsu/internal/ports"
+	"regexp"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Filter reports whether a route handles the update
+type Filter func(ctx context.Context, update *tgbotapi.Update) bool
+
+// And passes the updates that pass every filter
+func And(filters ...Filter) Filter {
+	return func(ctx context.Context, update *tgbotapi.Update) bool {
+		for _, filter := range filters {
+			if !filter(ctx, update) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or passes the updates that pass any of the filters
+func Or(filters ...Filter) Filter {
+	return func(ctx context.Context, update *tgbotapi.Update) bool {
+		for _, filter := range filters {
+			if filter(ctx, update) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not passes the updates the filter rejects
+func Not(filter Filter) Filter {
+	return func(ctx context.Context, update *tgbotapi.Update) bool {
+		return !filter(ctx, update)
+	}
+}
+
+// Command passes the commands with one of the names, without the slash
+func Command(names ...string) Filter {
+	return func(ctx context.Context, update *tgbotapi.Update) bool {
+		return update.Message != nil && update.Message.IsCommand() && slices.Contains(names, update.Message.Command())
+	}
+}
+
+// Text passes the messages whose text or caption matches the regular expression
+func Text(pattern string) Filter {
+	re := regexp.MustCompile(pattern)
+
+	return func(ctx context.Context, update *tgbotapi.Update) bool {
+		if update.Message == nil {
+			return false
+		}
+
+		text := update.Message.Text
+		if text == "" {
+			text = update.Message.Caption
+		}
+		return re.MatchString(text)
+	}
+}
+
+// CallbackData passes the callback queries whose data matches the regular expression
+func CallbackData(pattern string) Filter {
+	re := regexp.MustCompile(pattern)
+
+	return func(ctx context.Context, update *tgbotapi.Update) bool {
+		return update.CallbackQuery != nil && re.MatchString(update.CallbackQuery.Data)
+	}
+}
+
+// ChatType passes the updates from the types of chats: private, group, supergroup or channel
+func ChatType(types ...string) Filter {
+	return func(ctx context.Context, update *tgbotapi.Update) bool {
+		chat := update.FromChat()
+		return chat != nil && slices.Contains(types, chat.Type)
+	}
+}
+
+// Role passes the updates from registered users with any of the roles, see auth.RoleNames
+func Role(users ports.UserRepository, roles ...int) Filter {
+	return func(ctx context.Context, update *tgbotapi.Update) bool {
+		from := update.SentFrom()
+		if from == nil {
+			if update.PollAnswer == nil {
+				return false
+			}
+			from = &update.PollAnswer.User
+		}
+
+		user, err := users.UserByTelegramID(ctx, from.ID)
+		if err != nil {
+			return false
+		}
+
+		return auth.New(user).HasAnyRole(roles...)
+	}
+}
@@ -0,0 +1,184 @@
+package fsm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"kahoot_bsu/internal/domain/models"
+	"slices"
+	"strings"
+)
+
+// ErrUndeclaredTransition is returned by FSMContext.Set for a transition missing in the graph
+var ErrUndeclaredTransition = errors.New("undeclared state transition")
+
+// Transition is an edge of the graph, Label says what causes it
+type Transition struct {
+	From  models.State
+	To    models.State
+	Label string
+}
+
+// Graph is the declared transitions between the states of a conversation.
+// Staying in the same state is always allowed.
+type Graph struct {
+	transitions []Transition
+}
+
+// NewGraph creates an empty graph
+func NewGraph() *Graph {
+	return &Graph{}
+}
+
+// Add declares a transition
+func (g *Graph) Add(from, to models.State, label string) *Graph {
+	g.transitions = append(g.transitions, Transition{From: from, To: to, Label: label})
+	return g
+}
+
+// Empty reports whether no transitions were declared, then every transition is allowed
+func (g *Graph) Empty() bool {
+	return len(g.transitions) == 0
+}
+
+// Allowed reports whether the transition was declared
+func (g *Graph) Allowed(from, to models.State) bool {
+	if g.Empty() || from == to {
+		return true
+	}
+
+	return slices.ContainsFunc(g.transitions, func(t Transition) bool {
+		return t.From == from && t.To == to
+	})
+}
+
+// States returns the states of the graph in the order they were declared
+func (g *Graph) States() []models.State {
+	var states []models.State
+	for _, t := range g.transitions {
+		for _, state := range []models.State{t.From, t.To} {
+			if !slices.Contains(states, state) {
+				states = append(states, state)
+			}
+		}
+	}
+	return states
+}
+
+// Reachable returns the states reachable from the state, breadth first
+func (g *Graph) Reachable(from models.State) []models.State {
+	reached := []models.State{from}
+	for i := 0; i < len(reached); i++ {
+		for _, t := range g.transitions {
+			if t.From == reached[i] && !slices.Contains(reached, t.To) {
+				reached = append(reached, t.To)
+			}
+		}
+	}
+	return reached[1:]
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language
+func (g *Graph) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+
+	b.WriteString("digraph fsm {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box, style=rounded];\n")
+	for _, state := range g.States() {
+		fmt.Fprintf(b, "\t%q;\n", stateName(state))
+	}
+	for _, t := range g.transitions {
+		fmt.Fprintf(b, "\t%q -> %q [label=%q];\n", stateName(t.From), stateName(t.To), t.Label)
+	}
+	b.WriteString("}\n")
+
+	return b.Flush()
+}
+
+// WriteDrawIO writes the graph as a draw.io diagram, the states are laid out
+// in columns by their distance from the default state
+func (g *Graph) WriteDrawIO(w io.Writer) error {
+	const (
+		width   = 140
+		height  = 60
+		columnX = 220
+		rowY    = 120
+	)
+
+	states := g.States()
+	column := g.columns()
+	rows := make(map[int]int)
+
+	b := bufio.NewWriter(w)
+	b.WriteString(`<mxfile host="app.diagrams.net">` + "\n")
+	b.WriteString(`  <diagram name="FSM" id="fsm">` + "\n")
+	b.WriteString(`    <mxGraphModel grid="1" gridSize="10" guides="1" tooltips="1" connect="1" arrows="1" fold="1" page="1" pageScale="1" pageWidth="827" pageHeight="1169" math="0" shadow="0">` + "\n")
+	b.WriteString("      <root>\n")
+	b.WriteString(`        <mxCell id="0" />` + "\n")
+	b.WriteString(`        <mxCell id="1" parent="0" />` + "\n")
+
+	for i, state := range states {
+		col := column[state]
+		row := rows[col]
+		rows[col]++
+
+		fmt.Fprintf(b, `        <mxCell id="state-%d" value="%s" style="rounded=1;whiteSpace=wrap;html=1;" vertex="1" parent="1">`+"\n",
+			i, html.EscapeString(stateName(state)))
+		fmt.Fprintf(b, `          <mxGeometry x="%d" y="%d" width="%d" height="%d" as="geometry" />`+"\n",
+			40+col*columnX, 40+row*rowY, width, height)
+		b.WriteString("        </mxCell>\n")
+	}
+
+	for i, t := range g.transitions {
+		fmt.Fprintf(b, `        <mxCell id="transition-%d" value="%s" style="edgeStyle=orthogonalEdgeStyle;rounded=0;orthogonalLoop=1;jettySize=auto;html=1;" edge="1" parent="1" source="state-%d" target="state-%d">`+"\n",
+			i, html.EscapeString(t.Label), slices.Index(states, t.From), slices.Index(states, t.To))
+		b.WriteString(`          <mxGeometry relative="1" as="geometry" />` + "\n")
+		b.WriteString("        </mxCell>\n")
+	}
+
+	b.WriteString("      </root>\n")
+	b.WriteString("    </mxGraphModel>\n")
+	b.WriteString("  </diagram>\n")
+	b.WriteString("</mxfile>\n")
+
+	return b.Flush()
+}
+
+// columns places the default state first and every other state one column
+// after the closest state leading to it
+func (g *Graph) columns() map[models.State]int {
+	column := map[models.State]int{DefaultState: 0}
+	queue := []models.State{DefaultState}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, t := range g.transitions {
+			if _, ok := column[t.To]; t.From == from && !ok {
+				column[t.To] = column[from] + 1
+				queue = append(queue, t.To)
+			}
+		}
+	}
+
+	// States not reachable from the default state go to the last column
+	last := 0
+	for _, col := range column {
+		last = max(last, col)
+	}
+	for _, state := range g.States() {
+		if _, ok := column[state]; !ok {
+			column[state] = last + 1
+		}
+	}
+	return column
+}
+
+func stateName(state models.State) string {
+	if state == DefaultState {
+		return "default"
+	}
+	return strings.ReplaceAll(string(state), `"`, "'")
+}
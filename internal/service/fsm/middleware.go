@@ -0,0 +1,136 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"kahoot_bsu/internal/auth"
+	"kahoot_bsu/internal/domain/models"
+	"kahoot_bsu/internal/ports"
+	"kahoot_bsu/pkg/ratelimit"
+	"log/slog"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrHandlerPanic is returned by Recovery when a handler panics
+var ErrHandlerPanic = errors.New("state handler panicked")
+
+// Middleware wraps a handler, e.g. to log it or to stop the message before it
+type Middleware func(next UpdateHandlerFunc) UpdateHandlerFunc
+
+// chain wraps the handler so that the first middleware runs first
+func chain(handler UpdateHandlerFunc, middlewares []Middleware) UpdateHandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Recovery turns a panic in the handler into an ErrHandlerPanic error
+func Recovery(log *slog.Logger) Middleware {
+	return func(next UpdateHandlerFunc) UpdateHandlerFunc {
+		return func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error("Panic in state handler",
+						"chat_id", fsm.chatID, "user_id", fsm.userID, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+					err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+				}
+			}()
+
+			return next(ctx, fsm, update, bot)
+		}
+	}
+}
+
+// Logging logs every handled update with its chat, user, state and duration
+func Logging(log *slog.Logger) Middleware {
+	return func(next UpdateHandlerFunc) UpdateHandlerFunc {
+		return func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error {
+			started := time.Now()
+			state, _ := fsm.Current()
+
+			err := next(ctx, fsm, update, bot)
+
+			attrs := []any{"chat_id", fsm.chatID, "user_id", fsm.userID, "state", state, "update", updateKind(update), "duration", time.Since(started)}
+			if err != nil {
+				log.Error("State handler failed", append(attrs, "error", err)...)
+			} else {
+				log.Debug("State handler done", attrs...)
+			}
+
+			return err
+		}
+	}
+}
+
+// Metrics counts the handled updates, failures and handling time per state
+// as "<state>.handled", "<state>.errors" and "<state>.duration_ms" in the map,
+// e.g. expvar.NewMap("fsm") publishes them with the other expvar variables
+func Metrics(metrics *expvar.Map) Middleware {
+	return func(next UpdateHandlerFunc) UpdateHandlerFunc {
+		return func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error {
+			started := time.Now()
+			state, _ := fsm.Current()
+
+			err := next(ctx, fsm, update, bot)
+
+			name := string(state)
+			if name == "" {
+				name = "default"
+			}
+			metrics.Add(name+".handled", 1)
+			metrics.Add(name+".duration_ms", time.Since(started).Milliseconds())
+			if err != nil {
+				metrics.Add(name+".errors", 1)
+			}
+
+			return err
+		}
+	}
+}
+
+// RejectBlocked stops the updates of blocked users,
+// users who are not registered yet pass
+func RejectBlocked(users ports.UserRepository) Middleware {
+	return func(next UpdateHandlerFunc) UpdateHandlerFunc {
+		return func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error {
+			user, err := users.UserByTelegramID(ctx, fsm.userID)
+			if err != nil {
+				var userNotFoundErr ports.UserNotFoundError
+				if errors.As(err, &userNotFoundErr) {
+					return next(ctx, fsm, update, bot)
+				}
+				return err
+			}
+
+			if auth.New(user).IsBlocked() {
+				msg := tgbotapi.NewMessage(fsm.chatID, "⛔ Ваш аккаунт заблокирован.")
+				_, err := bot.Telegram.Send(msg)
+				return err
+			}
+
+			return next(ctx, fsm, update, bot)
+		}
+	}
+}
+
+// Throttle drops the updates of a user beyond rate per second with bursts of burst
+func Throttle(rate float64, burst int) Middleware {
+	limiter := ratelimit.NewKeyed(rate, burst, 10*time.Minute)
+
+	return func(next UpdateHandlerFunc) UpdateHandlerFunc {
+		return func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error {
+			if !limiter.Allow(strconv.FormatInt(fsm.userID, 10)) {
+				return nil
+			}
+
+			return next(ctx, fsm, update, bot)
+		}
+	}
+}
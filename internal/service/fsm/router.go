@@ -0,0 +1,367 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"kahoot_bsu/internal/domain/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrNoHandler is returned when no handler of the current state matches the update
+var ErrNoHandler = errors.New("no handler for state")
+
+// HandlerFunc is a function that handles a message in a specific state
+type HandlerFunc func(ctx context.Context, fsm *FSMContext, message *tgbotapi.Message, bot *models.Bot) error
+
+// CallbackHandlerFunc handles a tap on an inline keyboard button in a specific state
+type CallbackHandlerFunc func(ctx context.Context, fsm *FSMContext, query *tgbotapi.CallbackQuery, bot *models.Bot) error
+
+// InlineQueryHandlerFunc handles an inline query in a specific state
+type InlineQueryHandlerFunc func(ctx context.Context, fsm *FSMContext, query *tgbotapi.InlineQuery, bot *models.Bot) error
+
+// PollAnswerHandlerFunc handles a vote in a non-anonymous poll in a specific state
+type PollAnswerHandlerFunc func(ctx context.Context, fsm *FSMContext, answer *tgbotapi.PollAnswer, bot *models.Bot) error
+
+// UpdateHandlerFunc handles any update, the handlers of every kind are wrapped into it
+// and middlewares wrap it
+type UpdateHandlerFunc func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error
+
+// Kinds of updates routes are registered for
+const (
+	kindMessage     = "message"
+	kindCommand     = "command"
+	kindDocument    = "document"
+	kindCallback    = "callback_query"
+	kindInlineQuery = "inline_query"
+	kindPollAnswer  = "poll_answer"
+)
+
+// Route is a registered handler, it handles the updates of its kind that pass all of its filters
+type Route struct {
+	kind        string
+	handler     UpdateHandlerFunc
+	filters     []Filter
+	middlewares []Middleware
+}
+
+// Filter adds filters to the route
+func (r *Route) Filter(filters ...Filter) *Route {
+	r.filters = append(r.filters, filters...)
+	return r
+}
+
+// Use adds middlewares that wrap only this route
+func (r *Route) Use(middlewares ...Middleware) *Route {
+	r.middlewares = append(r.middlewares, middlewares...)
+	return r
+}
+
+func (r *Route) matches(ctx context.Context, update *tgbotapi.Update) bool {
+	for _, filter := range r.filters {
+		if !filter(ctx, update) {
+			return false
+		}
+	}
+	return true
+}
+
+// Handlers registers handlers for a state, a state group or any state
+type Handlers struct {
+	routes []*Route
+}
+
+// Message registers a handler for text messages, commands go to Command handlers
+func (h *Handlers) Message(handler HandlerFunc, filters ...Filter) *Route {
+	return h.add(kindMessage, messageHandler(handler), filters)
+}
+
+// Command registers a handler for commands, select them with the Command filter
+func (h *Handlers) Command(handler HandlerFunc, filters ...Filter) *Route {
+	return h.add(kindCommand, messageHandler(handler), filters)
+}
+
+// Document registers a handler for uploaded documents,
+// documents go to the Message handlers if no Document handler matches
+func (h *Handlers) Document(handler HandlerFunc, filters ...Filter) *Route {
+	return h.add(kindDocument, messageHandler(handler), filters)
+}
+
+// Callback registers a handler for taps on inline keyboard buttons,
+// select the buttons with the CallbackData filter
+func (h *Handlers) Callback(handler CallbackHandlerFunc, filters ...Filter) *Route {
+	return h.add(kindCallback, func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error {
+		return handler(ctx, fsm, update.CallbackQuery, bot)
+	}, filters)
+}
+
+// InlineQuery registers a handler for inline queries
+func (h *Handlers) InlineQuery(handler InlineQueryHandlerFunc, filters ...Filter) *Route {
+	return h.add(kindInlineQuery, func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error {
+		return handler(ctx, fsm, update.InlineQuery, bot)
+	}, filters)
+}
+
+// PollAnswer registers a handler for votes in polls
+func (h *Handlers) PollAnswer(handler PollAnswerHandlerFunc, filters ...Filter) *Route {
+	return h.add(kindPollAnswer, func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error {
+		return handler(ctx, fsm, update.PollAnswer, bot)
+	}, filters)
+}
+
+func (h *Handlers) add(kind string, handler UpdateHandlerFunc, filters []Filter) *Route {
+	route := &Route{kind: kind, handler: handler, filters: filters}
+	h.routes = append(h.routes, route)
+	return route
+}
+
+// Router manages state transitions and handlers (similar to aiogram's Router).
+//
+// An update goes to the first matching handler, tried in a fixed order:
+// the handlers of the exact state, then of its state group, then of any state,
+// each in the order they were registered.
+type Router struct {
+	Storage        Storage
+	states         map[models.State]*Handlers
+	groups         map[models.StateGroup]*Handlers
+	anyState       *Handlers
+	defaultHandler UpdateHandlerFunc
+	middlewares    []Middleware
+	graph          *Graph
+}
+
+// NewRouter creates a new router
+func NewRouter(storage Storage) *Router {
+	return &Router{
+		Storage:  storage,
+		states:   make(map[models.State]*Handlers),
+		groups:   make(map[models.StateGroup]*Handlers),
+		anyState: &Handlers{},
+		graph:    NewGraph(),
+	}
+}
+
+// Transition declares that a handler may move the conversation from one state to another.
+// Once a transition is declared, handlers can only make the declared ones.
+func (r *Router) Transition(from, to models.State, label string) {
+	r.graph.Add(from, to, label)
+}
+
+// Graph returns the declared transitions
+func (r *Router) Graph() *Graph {
+	return r.graph
+}
+
+// Validate checks that every state reachable from the default state has a handler,
+// call it at startup after the handlers and transitions are registered
+func (r *Router) Validate() error {
+	var errs []error
+	for _, state := range r.graph.Reachable(DefaultState) {
+		if state == DefaultState || r.handles(state) {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("state %q is reachable but has no handler", state))
+	}
+	return errors.Join(errs...)
+}
+
+// handles reports whether any route applies to the state
+func (r *Router) handles(state models.State) bool {
+	levels := []*Handlers{r.states[state], r.anyState}
+	if group := state.Group(); group != "" {
+		levels = append(levels, r.groups[group])
+	}
+
+	for _, handlers := range levels {
+		if handlers != nil && len(handlers.routes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Use adds middlewares that wrap the handlers of every state,
+// they run before the middlewares of the route in the order they were added
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// State returns the handlers of a state
+func (r *Router) State(state models.State) *Handlers {
+	return handlersOf(r.states, state)
+}
+
+// StateGroup returns the handlers of every state of the group, "group:*"
+func (r *Router) StateGroup(group models.StateGroup) *Handlers {
+	return handlersOf(r.groups, group)
+}
+
+// AnyState returns the handlers of every state, including the default one
+func (r *Router) AnyState() *Handlers {
+	return r.anyState
+}
+
+// Register registers a handler for a specific state (similar to aiogram's router.message decorator),
+// the middlewares wrap only this handler
+func (r *Router) Register(state models.State, handler HandlerFunc, middlewares ...Middleware) {
+	r.State(state).Message(handler).Use(middlewares...)
+}
+
+// Document registers a handler for the documents uploaded in a specific state
+func (r *Router) Document(state models.State, handler HandlerFunc, middlewares ...Middleware) {
+	r.State(state).Document(handler).Use(middlewares...)
+}
+
+// Callback registers a handler for the callback queries in a specific state
+// whose data matches the regular expression pattern
+func (r *Router) Callback(state models.State, pattern string, handler CallbackHandlerFunc, middlewares ...Middleware) {
+	r.State(state).Callback(handler, CallbackData(pattern)).Use(middlewares...)
+}
+
+// InlineQuery registers a handler for the inline queries in a specific state
+func (r *Router) InlineQuery(state models.State, handler InlineQueryHandlerFunc, middlewares ...Middleware) {
+	r.State(state).InlineQuery(handler).Use(middlewares...)
+}
+
+// PollAnswer registers a handler for the poll votes in a specific state
+func (r *Router) PollAnswer(state models.State, handler PollAnswerHandlerFunc, middlewares ...Middleware) {
+	r.State(state).PollAnswer(handler).Use(middlewares...)
+}
+
+// DefaultMessage sets the handler of the messages no other handler matches
+func (r *Router) DefaultMessage(handler HandlerFunc, middlewares ...Middleware) {
+	r.defaultHandler = chain(messageHandler(handler), middlewares)
+}
+
+// Dispatch passes the update to the handler of the current state of its user.
+// It returns ErrNoHandler if no handler matches, so the update can be routed elsewhere.
+func (r *Router) Dispatch(ctx context.Context, update *tgbotapi.Update, bot *models.Bot) error {
+	chatID, userID, ok := updateKey(update)
+	if !ok {
+		return ErrNoHandler
+	}
+
+	return r.dispatch(ctx, NewFSMContext(ctx, r.Storage, chatID, userID), update, bot)
+}
+
+// Run passes the update to the handler through the middlewares of the router,
+// for the updates handled outside of the states, e.g. global commands.
+// The handler can set any state, its transitions are not checked.
+func (r *Router) Run(ctx context.Context, update *tgbotapi.Update, bot *models.Bot, handler UpdateHandlerFunc) error {
+	chatID, userID, ok := updateKey(update)
+	if !ok {
+		return ErrNoHandler
+	}
+
+	return chain(handler, r.middlewares)(ctx, NewFSMContext(ctx, r.Storage, chatID, userID), update, bot)
+}
+
+// ProcessUpdate processes a message based on the current FSM state (similar to aiogram's Dispatcher)
+func (r *Router) ProcessUpdate(ctx context.Context, message *tgbotapi.Message, bot *models.Bot, fsm *FSMContext) error {
+	return r.dispatch(ctx, fsm, &tgbotapi.Update{Message: message}, bot)
+}
+
+func (r *Router) dispatch(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error {
+	state, err := fsm.Current()
+	if err != nil {
+		return err
+	}
+	fsm.expect(state, r.graph)
+
+	handler := r.match(ctx, state, update)
+	if handler == nil {
+		if updateKind(update) != kindMessage || r.defaultHandler == nil {
+			return ErrNoHandler
+		}
+		handler = r.defaultHandler
+	}
+
+	return chain(handler, r.middlewares)(ctx, fsm, update, bot)
+}
+
+// match finds the first route of the state that handles the update
+func (r *Router) match(ctx context.Context, state models.State, update *tgbotapi.Update) UpdateHandlerFunc {
+	levels := []*Handlers{r.states[state]}
+	if group := state.Group(); group != "" {
+		levels = append(levels, r.groups[group])
+	}
+	levels = append(levels, r.anyState)
+
+	kinds := []string{updateKind(update)}
+	if kinds[0] == kindDocument {
+		kinds = append(kinds, kindMessage)
+	}
+
+	for _, handlers := range levels {
+		if handlers == nil {
+			continue
+		}
+		for _, kind := range kinds {
+			for _, route := range handlers.routes {
+				if route.kind == kind && route.matches(ctx, update) {
+					return chain(route.handler, route.middlewares)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// messageHandler adapts a message handler to the updates
+func messageHandler(handler HandlerFunc) UpdateHandlerFunc {
+	return func(ctx context.Context, fsm *FSMContext, update *tgbotapi.Update, bot *models.Bot) error {
+		return handler(ctx, fsm, update.Message, bot)
+	}
+}
+
+// handlersOf returns the handlers of the key, creating them if needed
+func handlersOf[K comparable](handlers map[K]*Handlers, key K) *Handlers {
+	h, ok := handlers[key]
+	if !ok {
+		h = &Handlers{}
+		handlers[key] = h
+	}
+	return h
+}
+
+// updateKind names the kind of an update
+func updateKind(update *tgbotapi.Update) string {
+	switch {
+	case update.Message != nil && update.Message.IsCommand():
+		return kindCommand
+	case update.Message != nil && update.Message.Document != nil:
+		return kindDocument
+	case update.Message != nil:
+		return kindMessage
+	case update.CallbackQuery != nil:
+		return kindCallback
+	case update.InlineQuery != nil:
+		return kindInlineQuery
+	case update.PollAnswer != nil:
+		return kindPollAnswer
+	}
+	return "unknown"
+}
+
+// updateKey returns the chat and user whose state an update belongs to.
+// Inline queries and poll answers come without a chat and use the private chat of the user.
+func updateKey(update *tgbotapi.Update) (int64, int64, bool) {
+	switch {
+	case update.Message != nil && update.Message.From != nil:
+		return update.Message.Chat.ID, update.Message.From.ID, true
+	case update.CallbackQuery != nil:
+		chatID := update.CallbackQuery.From.ID
+		if update.CallbackQuery.Message != nil {
+			chatID = update.CallbackQuery.Message.Chat.ID
+		}
+		return chatID, update.CallbackQuery.From.ID, true
+	case update.InlineQuery != nil:
+		return update.InlineQuery.From.ID, update.InlineQuery.From.ID, true
+	case update.PollAnswer != nil:
+		return update.PollAnswer.User.ID, update.PollAnswer.User.ID, true
+	}
+	return 0, 0, false
+}
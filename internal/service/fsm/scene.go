@@ -0,0 +1,268 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/domain/models"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Commands available in every step of a scene
+const (
+	SceneBackCommand   = "back"
+	SceneCancelCommand = "cancel"
+)
+
+// InputError rejects an answer, the message is sent to the user and the step is asked again
+type InputError struct {
+	Message string
+}
+
+func (e InputError) Error() string {
+	return e.Message
+}
+
+// Step is one question of a scene
+type Step struct {
+	// Name is the data key the answer is collected under
+	Name string
+	// State the scene waits in for the answer, "<scene>:<name>" by default
+	State models.State
+	// Prompt asks for the answer, ParseMode applies to it
+	Prompt    string
+	ParseMode string
+	// Enter runs in the transaction of the transition to the step, before the prompt
+	Enter func(ctx context.Context, fsm *FSMContext) error
+	// Entered runs once the transition is committed, before the prompt,
+	// for effects that cannot be rolled back such as sending an email
+	Entered func(ctx context.Context, fsm *FSMContext) error
+	// Validate checks the answer and returns the value to collect,
+	// the text is collected as is when it is nil
+	Validate func(ctx context.Context, fsm *FSMContext, text string) (string, error)
+}
+
+// CommitFunc finishes a scene with the collected answers,
+// the scene stays in its last step when it fails
+type CommitFunc func(ctx context.Context, fsm *FSMContext, message *tgbotapi.Message, bot *models.Bot, data map[string]string) error
+
+// Scene is a multi-step dialog. It asks the questions of its steps in order,
+// collects the answers into the FSM data and commits them after the last one.
+// /back asks the previous question again and /cancel leaves the scene.
+type Scene struct {
+	name       string
+	steps      []Step
+	commit     CommitFunc
+	done       models.State
+	cancelText string
+}
+
+// SceneOption configures a Scene
+type SceneOption func(*Scene)
+
+// WithDoneState sets the state the scene ends in, the default state by default
+func WithDoneState(state models.State) SceneOption {
+	return func(s *Scene) {
+		s.done = state
+	}
+}
+
+// WithCancelText sets the message sent when the user leaves the scene
+func WithCancelText(text string) SceneOption {
+	return func(s *Scene) {
+		s.cancelText = text
+	}
+}
+
+// NewScene creates a scene, register it on the router before entering it
+func NewScene(name string, steps []Step, commit CommitFunc, opts ...SceneOption) *Scene {
+	s := &Scene{
+		name:       name,
+		steps:      steps,
+		commit:     commit,
+		done:       DefaultState,
+		cancelText: "Действие отменено",
+	}
+	for i := range s.steps {
+		if s.steps[i].State == "" {
+			s.steps[i].State = models.State(name + ":" + s.steps[i].Name)
+		}
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Name returns the name of the scene
+func (s *Scene) Name() string {
+	return s.name
+}
+
+// Register registers the handlers of the steps and declares the transitions of the scene
+func (s *Scene) Register(router *Router) {
+	router.Transition(DefaultState, s.steps[0].State, s.name)
+
+	for i, step := range s.steps {
+		handlers := router.State(step.State)
+		handlers.Command(s.back, Command(SceneBackCommand))
+		handlers.Command(s.cancel, Command(SceneCancelCommand))
+		handlers.Message(s.answer)
+
+		if i > 0 {
+			router.Transition(step.State, s.steps[i-1].State, "/"+SceneBackCommand)
+		}
+		if i < len(s.steps)-1 {
+			router.Transition(step.State, s.steps[i+1].State, step.Name)
+		} else {
+			router.Transition(step.State, s.done, step.Name)
+		}
+		router.Transition(step.State, DefaultState, "/"+SceneCancelCommand)
+	}
+}
+
+// Enter starts the scene from the first step, the answers of a previous run are dropped
+func (s *Scene) Enter(fsm *FSMContext, bot *models.Bot) error {
+	if err := fsm.ClearData(); err != nil {
+		return fmt.Errorf("failed to clear scene data: %w", err)
+	}
+
+	return s.enterStep(fsm, bot, 0)
+}
+
+// enterStep moves the scene to the step and asks its question
+func (s *Scene) enterStep(fsm *FSMContext, bot *models.Bot, i int) error {
+	step := s.steps[i]
+
+	err := fsm.InTx(func(tx *FSMContext) error {
+		if err := tx.Set(step.State); err != nil {
+			return err
+		}
+		if step.Enter != nil {
+			return step.Enter(tx.Context(), tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if step.Entered != nil {
+		if err := step.Entered(fsm.Context(), fsm); err != nil {
+			return err
+		}
+	}
+
+	return s.prompt(fsm, bot, i)
+}
+
+func (s *Scene) prompt(fsm *FSMContext, bot *models.Bot, i int) error {
+	step := s.steps[i]
+
+	hint := "/" + SceneCancelCommand + " — отменить"
+	if i > 0 {
+		hint = "/" + SceneBackCommand + " — назад, " + hint
+	}
+
+	msg := tgbotapi.NewMessage(fsm.ChatID(), step.Prompt+"\n\n"+hint)
+	msg.ParseMode = step.ParseMode
+	_, err := bot.Telegram.Send(msg)
+	return err
+}
+
+// step returns the index of the step the user is in
+func (s *Scene) step(fsm *FSMContext) (int, error) {
+	state, err := fsm.Current()
+	if err != nil {
+		return 0, err
+	}
+
+	i := slices.IndexFunc(s.steps, func(step Step) bool { return step.State == state })
+	if i < 0 {
+		return 0, fmt.Errorf("state %q is not a step of scene %s", state, s.name)
+	}
+	return i, nil
+}
+
+func (s *Scene) answer(ctx context.Context, fsm *FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	i, err := s.step(fsm)
+	if err != nil {
+		return err
+	}
+	step := s.steps[i]
+
+	value := message.Text
+	if step.Validate != nil {
+		value, err = step.Validate(ctx, fsm, message.Text)
+
+		var inputErr InputError
+		if errors.As(err, &inputErr) {
+			_, err := bot.Telegram.Send(tgbotapi.NewMessage(message.Chat.ID, inputErr.Message))
+			return err
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := NewData[string](step.Name).Set(fsm, value); err != nil {
+		return fmt.Errorf("failed to collect %s: %w", step.Name, err)
+	}
+
+	if i < len(s.steps)-1 {
+		return s.enterStep(fsm, bot, i+1)
+	}
+
+	data, err := s.collect(fsm)
+	if err != nil {
+		return err
+	}
+
+	// Without a transactional storage a failed commit must not leave
+	// the user in the done state, so the state is moved after it
+	return fsm.InTx(func(tx *FSMContext) error {
+		if err := s.commit(tx.Context(), tx, message, bot, data); err != nil {
+			return err
+		}
+		return tx.Set(s.done)
+	})
+}
+
+// collect reads the answers of all steps
+func (s *Scene) collect(fsm *FSMContext) (map[string]string, error) {
+	data := make(map[string]string, len(s.steps))
+	for _, step := range s.steps {
+		value, _, err := NewData[string](step.Name).Get(fsm)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", step.Name, err)
+		}
+		data[step.Name] = value
+	}
+	return data, nil
+}
+
+func (s *Scene) back(ctx context.Context, fsm *FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	i, err := s.step(fsm)
+	if err != nil {
+		return err
+	}
+
+	if i == 0 {
+		return s.prompt(fsm, bot, i)
+	}
+	return s.enterStep(fsm, bot, i-1)
+}
+
+func (s *Scene) cancel(ctx context.Context, fsm *FSMContext, message *tgbotapi.Message, bot *models.Bot) error {
+	if err := fsm.Set(DefaultState); err != nil {
+		return err
+	}
+	if err := fsm.ClearData(); err != nil {
+		return fmt.Errorf("failed to clear scene data: %w", err)
+	}
+
+	_, err := bot.Telegram.Send(tgbotapi.NewMessage(message.Chat.ID, s.cancelText))
+	return err
+}
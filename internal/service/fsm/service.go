@@ -3,15 +3,18 @@ package fsm
 import (
 	"context"
 	"errors"
+	"fmt"
 	"slices"
 
 	"kahoot_bsu/internal/domain/models"
-
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // Fix me
 
+// ErrStateChanged is returned when the state was changed by another update
+// after the handler was chosen, the transition is not applied
+var ErrStateChanged = errors.New("state changed concurrently")
+
 // Storage interface for persisting FSM states
 type Storage interface {
 	// Get current state for a user in a specific chat
@@ -20,6 +23,10 @@ type Storage interface {
 	// Set state for a user in a specific chat
 	Set(ctx context.Context, chatID int64, userID int64, state models.State) error
 
+	// CompareAndSet sets the state only if the current one is from,
+	// it reports whether the state was set
+	CompareAndSet(ctx context.Context, chatID int64, userID int64, from, to models.State) (bool, error)
+
 	// Remove state for a user in a specific chat
 	Delete(ctx context.Context, chatID int64, userID int64) error
 
@@ -33,16 +40,29 @@ type Storage interface {
 	ClearData(ctx context.Context, chatID int64, userID int64) error
 }
 
+// Transactional is a Storage whose writes can be committed together
+// with the writes of the repositories sharing its database
+type Transactional interface {
+	Storage
+
+	// InTx runs fn in a transaction carried by the context passed to it
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // FSMContext manages the state for a specific user in a specific chat
 type FSMContext struct {
 	storage Storage
 	chatID  int64
 	userID  int64
 	ctx     context.Context
+	// guarded contexts only change the state from expected along the graph
+	guarded  bool
+	expected models.State
+	graph    *Graph
 }
 
 // NewFSMContext creates a new FSM context for a user in a chat
-func NewFSMContext(ctx context.Context, storage Storage, stateProvider  chatID, userID int64) *FSMContext {
+func NewFSMContext(ctx context.Context, storage Storage, chatID, userID int64) *FSMContext {
 	return &FSMContext{
 		storage: storage,
 		chatID:  chatID,
@@ -51,6 +71,49 @@ func NewFSMContext(ctx context.Context, storage Storage, stateProvider  chatID,
 	}
 }
 
+// ChatID returns the chat of the conversation
+func (f *FSMContext) ChatID() int64 {
+	return f.chatID
+}
+
+// UserID returns the user of the conversation
+func (f *FSMContext) UserID() int64 {
+	return f.userID
+}
+
+// Context returns the context of the update, it carries the transaction inside InTx
+func (f *FSMContext) Context() context.Context {
+	return f.ctx
+}
+
+// InTx runs fn on a context whose writes are committed together when fn succeeds.
+// Storages that are not Transactional run fn without a transaction.
+func (f *FSMContext) InTx(fn func(tx *FSMContext) error) error {
+	storage, ok := f.storage.(Transactional)
+	if !ok {
+		return fn(f)
+	}
+
+	return storage.InTx(f.ctx, func(ctx context.Context) error {
+		tx := *f
+		tx.ctx = ctx
+		if err := fn(&tx); err != nil {
+			return err
+		}
+
+		f.expected = tx.expected
+		return nil
+	})
+}
+
+// expect makes Set fail with ErrStateChanged unless the state is still state
+// and with ErrUndeclaredTransition for the transitions missing in the graph
+func (f *FSMContext) expect(state models.State, graph *Graph) {
+	f.guarded = true
+	f.expected = state
+	f.graph = graph
+}
+
 // Current gets the current state
 func (f *FSMContext) Current() (models.State, error) {
 	return f.storage.Get(f.ctx, f.chatID, f.userID)
@@ -70,9 +133,27 @@ func (f *FSMContext) IsInState(states ...models.State) (bool, error) {
 	return false, nil
 }
 
-// Set sets a new state
+// Set sets a new state. In the handlers of the router it only succeeds from the
+// state the handler was chosen for and returns ErrStateChanged otherwise.
 func (f *FSMContext) Set(state models.State) error {
-	return f.storage.Set(f.ctx, f.chatID, f.userID, state)
+	if !f.guarded {
+		return f.storage.Set(f.ctx, f.chatID, f.userID, state)
+	}
+
+	if f.graph != nil && !f.graph.Allowed(f.expected, state) {
+		return fmt.Errorf("%w: %q -> %q", ErrUndeclaredTransition, f.expected, state)
+	}
+
+	ok, err := f.storage.CompareAndSet(f.ctx, f.chatID, f.userID, f.expected, state)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrStateChanged
+	}
+
+	f.expected = state
+	return nil
 }
 
 // Finish resets the state to default (ends the conversation)
@@ -82,7 +163,7 @@ func (f *FSMContext) Finish() error {
 
 // ResetState resets the state but keeps the data
 func (f *FSMContext) ResetState() error {
-	return f.storage.Set(f.ctx, f.chatID, f.userID, models.DefaultState)
+	return f.Set(models.DefaultState)
 }
 
 // GetData gets data associated with current state
@@ -111,49 +192,6 @@ func (f *FSMContext) ClearData() error {
 	return f.storage.ClearData(f.ctx, f.chatID, f.userID)
 }
 
-// HandlerFunc is a function that handles a message in a specific state
-type HandlerFunc func(ctx context.Context, fsm *FSMContext, message *tgbotapi.Message, bot *models.Bot) error
-
-// Router manages state transitions and handlers (similar to aiogram's Router)
-type Router struct {
-	Storage        Storage
-	stateHandlers  map[models.State]HandlerFunc
-	defaultHandler HandlerFunc
-}
-
-// NewRouter creates a new router
-func NewRouter(storage Storage) *Router {
-	return &Router{
-		Storage:       storage,
-		stateHandlers: make(map[models.State]HandlerFunc),
-	}
-}
-
-// Message registers a handler for a specific state (similar to aiogram's router.message decorator)
-func (r *Router) Register(state models.State, handler HandlerFunc) {
-	r.stateHandlers[state] = handler
-}
-
-// DefaultMessage sets a default message handler for unhandled states
-func (r *Router) DefaultMessage(handler HandlerFunc) {
-	r.defaultHandler = handler
-}
-
-// ProcessUpdate processes an update based on the current FSM state (similar to aiogram's Dispatcher)
-func (r *Router) ProcessUpdate(ctx context.Context, message *tgbotapi.Message, bot *models.Bot, fsm *FSMContext) error {
-	state, err := fsm.Current()
-	if err != nil {
-		return err
-	}
-
-	handler, exists := r.stateHandlers[state]
-	if !exists {
-		return errors.New("no handler for state")
-	}
-
-	return handler(ctx, fsm, message, bot)
-}
-
 // // Start the bot and begin processing updates
 // func (r *Router) Start(bot *Bot) {
 // 	ctx := context.Background()
@@ -165,11 +203,11 @@ func (r *Router) ProcessUpdate(ctx context.Context, message *tgbotapi.Message, b
 // 	}
 // }
 
-// Define your states
+// States of the conversations, declared in models
 const (
-	DefaultState       models.State = ""
-	StateStart         models.State = "start"
-	StateAwaitingLogin models.State = "awaiting_login"
-	StateAwaitingOTP   models.State = "awaiting_otp"
-	StateRegistered    models.State = "registered"
+	DefaultState       = models.DefaultState
+	StateStart         = models.StateStart
+	StateAwaitingLogin = models.StateAwaitingLogin
+	StateAwaitingOTP   = models.StateAwaitingOTP
+	StateRegistered    = models.StateRegistered
 )
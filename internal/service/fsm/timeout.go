@@ -0,0 +1,191 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"kahoot_bsu/internal/domain/models"
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Timeout ends a conversation left in a state
+type Timeout struct {
+	// After resets the state and clears the data
+	After time.Duration
+	// Expired is sent to the user when the state is reset
+	Expired string
+	// RemindAfter sends the Reminder if the user is still in the state, 0 disables it
+	RemindAfter time.Duration
+	Reminder    string
+}
+
+// JobKind is what a scheduled job does
+type JobKind string
+
+const (
+	JobRemind JobKind = "remind"
+	JobExpire JobKind = "expire"
+)
+
+// Job is a scheduled action on the conversation of a user in a chat
+type Job struct {
+	ChatID int64        `json:"chat_id"`
+	UserID int64        `json:"user_id"`
+	State  models.State `json:"state"`
+	Kind   JobKind      `json:"kind"`
+	At     time.Time    `json:"at"`
+}
+
+// Scheduler keeps the jobs until they are due, it must survive restarts
+type Scheduler interface {
+	// Schedule runs the job at job.At
+	Schedule(ctx context.Context, job Job) error
+	// Cancel drops the jobs of the conversation
+	Cancel(ctx context.Context, chatID, userID int64) error
+	// Run passes the due jobs to handle until ctx is done
+	Run(ctx context.Context, handle func(ctx context.Context, job Job) error)
+}
+
+// Timeouts is a Storage that schedules the timeouts of the states it sets.
+// Jobs of a state are dropped when the state changes.
+type Timeouts struct {
+	Storage
+	scheduler Scheduler
+	timeouts  map[models.State]Timeout
+	bot       *models.Bot
+	log       *slog.Logger
+}
+
+// NewTimeouts wraps the storage, declare the timeouts before the states are set
+func NewTimeouts(storage Storage, scheduler Scheduler, bot *models.Bot, log *slog.Logger) *Timeouts {
+	return &Timeouts{
+		Storage:   storage,
+		scheduler: scheduler,
+		timeouts:  make(map[models.State]Timeout),
+		bot:       bot,
+		log:       log,
+	}
+}
+
+// Declare sets the timeout of a state
+func (t *Timeouts) Declare(state models.State, timeout Timeout) {
+	t.timeouts[state] = timeout
+}
+
+// Set implements Storage.Set and schedules the timeout of the new state
+func (t *Timeouts) Set(ctx context.Context, chatID int64, userID int64, state models.State) error {
+	if err := t.Storage.Set(ctx, chatID, userID, state); err != nil {
+		return err
+	}
+
+	return t.schedule(ctx, chatID, userID, state)
+}
+
+// CompareAndSet implements Storage.CompareAndSet and schedules the timeout of the new state
+func (t *Timeouts) CompareAndSet(ctx context.Context, chatID int64, userID int64, from, to models.State) (bool, error) {
+	ok, err := t.Storage.CompareAndSet(ctx, chatID, userID, from, to)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	return true, t.schedule(ctx, chatID, userID, to)
+}
+
+// schedule replaces the jobs of the conversation with the ones of the state
+func (t *Timeouts) schedule(ctx context.Context, chatID int64, userID int64, state models.State) error {
+	if err := t.scheduler.Cancel(ctx, chatID, userID); err != nil {
+		return fmt.Errorf("failed to cancel state timeout: %w", err)
+	}
+
+	timeout, ok := t.timeouts[state]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	if timeout.RemindAfter > 0 && timeout.RemindAfter < timeout.After {
+		job := Job{ChatID: chatID, UserID: userID, State: state, Kind: JobRemind, At: now.Add(timeout.RemindAfter)}
+		if err := t.scheduler.Schedule(ctx, job); err != nil {
+			return fmt.Errorf("failed to schedule reminder: %w", err)
+		}
+	}
+
+	job := Job{ChatID: chatID, UserID: userID, State: state, Kind: JobExpire, At: now.Add(timeout.After)}
+	if err := t.scheduler.Schedule(ctx, job); err != nil {
+		return fmt.Errorf("failed to schedule state timeout: %w", err)
+	}
+
+	return nil
+}
+
+// Delete implements Storage.Delete and drops the timeout of the state
+func (t *Timeouts) Delete(ctx context.Context, chatID int64, userID int64) error {
+	if err := t.Storage.Delete(ctx, chatID, userID); err != nil {
+		return err
+	}
+
+	return t.scheduler.Cancel(ctx, chatID, userID)
+}
+
+// InTx implements Transactional.InTx when the wrapped storage is transactional
+func (t *Timeouts) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	storage, ok := t.Storage.(Transactional)
+	if !ok {
+		return fn(ctx)
+	}
+
+	return storage.InTx(ctx, fn)
+}
+
+// Run sends the reminders and resets the expired states until ctx is done
+func (t *Timeouts) Run(ctx context.Context) {
+	t.scheduler.Run(ctx, t.handle)
+}
+
+func (t *Timeouts) handle(ctx context.Context, job Job) error {
+	timeout := t.timeouts[job.State]
+
+	switch job.Kind {
+	case JobRemind:
+		// The user may have moved on while the job was waiting
+		current, err := t.Storage.Get(ctx, job.ChatID, job.UserID)
+		if err != nil {
+			return err
+		}
+		if current != job.State {
+			return nil
+		}
+
+		return t.send(job.ChatID, timeout.Reminder)
+
+	case JobExpire:
+		// Only the state the job was scheduled for expires, an update of the user
+		// may move the conversation on at the same time
+		expired, err := t.Storage.CompareAndSet(ctx, job.ChatID, job.UserID, job.State, DefaultState)
+		if err != nil {
+			return err
+		}
+		if !expired {
+			return nil
+		}
+		if err := t.Storage.ClearData(ctx, job.ChatID, job.UserID); err != nil {
+			return err
+		}
+
+		t.log.Info("State expired", "chat_id", job.ChatID, "user_id", job.UserID, "state", job.State)
+		return t.send(job.ChatID, timeout.Expired)
+	}
+
+	return fmt.Errorf("unknown job kind: %s", job.Kind)
+}
+
+func (t *Timeouts) send(chatID int64, text string) error {
+	if text == "" {
+		return nil
+	}
+
+	_, err := t.bot.Telegram.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
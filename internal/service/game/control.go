@@ -0,0 +1,169 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"kahoot_bsu/internal/domain/models/session"
+	"time"
+)
+
+var (
+	ErrSessionNotStarted = errors.New("game session is not started")
+	ErrSessionStarted    = errors.New("game session is already started")
+	ErrSessionChanged    = errors.New("game session was changed by another request")
+)
+
+// Start opens the first question of a waiting session
+func (s *Service) Start(ctx context.Context, sessionID string) (*session.Session, error) {
+	var started *session.Session
+
+	err := s.sessionRepo.Update(ctx, sessionID, func(innerCtx context.Context, gameSession *session.Session) error {
+		if gameSession.Status&session.StatusWaiting == 0 {
+			return ErrSessionStarted
+		}
+
+		now := time.Now()
+		gameSession.Status = session.StatusActive
+		gameSession.CurrentQuestionIndex = 0
+		gameSession.QuestionOpenedAt = &now
+		gameSession.StartedAt = &now
+
+		started = gameSession
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.events.Publish(ctx, started)
+	return started, nil
+}
+
+// NextQuestion closes the current question and opens the next one.
+// The session is finished after the last question. Of concurrent calls
+// for the same question only the first one moves on, the rest get ErrSessionChanged.
+func (s *Service) NextQuestion(ctx context.Context, sessionID string) (*session.Session, error) {
+	observed, err := s.sessionRepo.Session(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.questionCount(ctx, observed)
+	if err != nil {
+		return nil, err
+	}
+
+	var next *session.Session
+	err = s.sessionRepo.Update(ctx, sessionID, func(innerCtx context.Context, gameSession *session.Session) error {
+		if gameSession.Status&(session.StatusActive|session.StatusPaused) == 0 {
+			return ErrSessionNotStarted
+		}
+		if gameSession.CurrentQuestionIndex != observed.CurrentQuestionIndex {
+			return ErrSessionChanged
+		}
+
+		// The session is locked, the question is closed once
+		if err := s.closeQuestion(innerCtx, gameSession); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		gameSession.CurrentQuestionIndex++
+
+		if gameSession.CurrentQuestionIndex >= count {
+			gameSession.Status = session.StatusFinished
+			gameSession.QuestionOpenedAt = nil
+			gameSession.EndedAt = &now
+		} else {
+			gameSession.Status = session.StatusActive
+			gameSession.QuestionOpenedAt = &now
+		}
+
+		next = gameSession
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.events.Publish(ctx, next)
+	return next, nil
+}
+
+// Pause stops accepting answers until the next question is opened
+func (s *Service) Pause(ctx context.Context, sessionID string) (*session.Session, error) {
+	var paused *session.Session
+
+	err := s.sessionRepo.Update(ctx, sessionID, func(innerCtx context.Context, gameSession *session.Session) error {
+		if gameSession.Status&session.StatusActive == 0 {
+			return ErrSessionNotStarted
+		}
+
+		gameSession.Status = session.StatusPaused
+		paused = gameSession
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.events.Publish(ctx, paused)
+	return paused, nil
+}
+
+// Finish ends the session, no more answers are accepted
+func (s *Service) Finish(ctx context.Context, sessionID string) (*session.Session, error) {
+	var finished *session.Session
+	err := s.sessionRepo.Update(ctx, sessionID, func(innerCtx context.Context, gameSession *session.Session) error {
+		if gameSession.Status&session.StatusFinished != 0 {
+			return ErrSessionFinished
+		}
+
+		if err := s.closeQuestion(innerCtx, gameSession); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		gameSession.Status = session.StatusFinished
+		gameSession.QuestionOpenedAt = nil
+		gameSession.EndedAt = &now
+
+		finished = gameSession
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.events.Publish(ctx, finished)
+	return finished, nil
+}
+
+// questionCount returns how many questions every participant of the session plays
+func (s *Service) questionCount(ctx context.Context, gameSession *session.Session) (int, error) {
+	questions, err := s.questionRepo.QuizQuestions(ctx, gameSession.QuizID)
+	if err != nil {
+		return 0, err
+	}
+
+	pools, err := s.poolRepo.QuizPools(ctx, gameSession.QuizID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(pools) == 0 {
+		return len(questions), nil
+	}
+
+	count := 0
+	for _, q := range questions {
+		if q.PoolID == "" {
+			count++
+		}
+	}
+	for _, pool := range pools {
+		count += pool.DrawCount
+	}
+
+	return count, nil
+}
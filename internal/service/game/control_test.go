@@ -0,0 +1,110 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/domain/models/session"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedSessions is a session.Repository with one session whose Update is
+// serialized like the row lock of the database. Session waits until reads
+// callers have read the session, so they all observe the same state.
+type lockedSessions struct {
+	session.Repository
+
+	mu      sync.Mutex
+	current session.Session
+	reads   sync.WaitGroup
+	closed  int
+}
+
+func (r *lockedSessions) Session(_ context.Context, _ string) (*session.Session, error) {
+	r.mu.Lock()
+	observed := r.current
+	r.mu.Unlock()
+
+	r.reads.Done()
+	r.reads.Wait()
+	return &observed, nil
+}
+
+func (r *lockedSessions) Update(ctx context.Context, _ string, updateFn func(context.Context, *session.Session) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	updated := r.current
+	if err := updateFn(ctx, &updated); err != nil {
+		return err
+	}
+	r.current = updated
+	return nil
+}
+
+func (r *lockedSessions) SessionAnswers(_ context.Context, _ string, _ time.Time) ([]*session.Answer, error) {
+	r.closed++
+	return nil, nil
+}
+
+type fixedQuestions struct {
+	question.Repository
+	questions []*question.Question
+}
+
+func (r fixedQuestions) QuizQuestions(_ context.Context, _ string) ([]*question.Question, error) {
+	return r.questions, nil
+}
+
+type noPools struct{}
+
+func (noPools) QuizPools(_ context.Context, _ string) ([]quiz.Pool, error) { return nil, nil }
+
+func (noPools) SavePools(_ context.Context, _ string, _ []quiz.Pool) error { return nil }
+
+func TestNextQuestionConcurrentCalls(t *testing.T) {
+	const calls = 2
+
+	opened := time.Now()
+	sessions := &lockedSessions{current: session.Session{
+		ID:               "s",
+		QuizID:           "q",
+		Status:           session.StatusActive,
+		QuestionOpenedAt: &opened,
+	}}
+	sessions.reads.Add(calls)
+
+	questions := fixedQuestions{questions: []*question.Question{{ID: "1"}, {ID: "2"}, {ID: "3"}}}
+	s := NewService(sessions, questions, noPools{}, nil)
+
+	errs := make(chan error, calls)
+	for range calls {
+		go func() {
+			_, err := s.NextQuestion(context.Background(), "s")
+			errs <- err
+		}()
+	}
+
+	var changed int
+	for range calls {
+		switch err := <-errs; {
+		case errors.Is(err, ErrSessionChanged):
+			changed++
+		case err != nil:
+			t.Fatalf("NextQuestion() error = %v", err)
+		}
+	}
+
+	if changed != calls-1 {
+		t.Errorf("NextQuestion() conflicts = %d, want %d", changed, calls-1)
+	}
+	if sessions.current.CurrentQuestionIndex != 1 {
+		t.Errorf("question index = %d, want 1", sessions.current.CurrentQuestionIndex)
+	}
+	if sessions.closed != 1 {
+		t.Errorf("question closed %d times, want 1", sessions.closed)
+	}
+}
@@ -0,0 +1,77 @@
+package game
+
+import (
+	"context"
+	"kahoot_bsu/internal/domain/models/session"
+	"sync"
+)
+
+// Events carries session state changes to the subscribers. The Hub serves
+// a single process, the API server and the bot share a transport instead,
+// as both of them change sessions and have players connected.
+type Events interface {
+	// Publish sends the session state to the subscribers without waiting for them
+	Publish(ctx context.Context, gameSession *session.Session)
+	// Subscribe returns a channel receiving every change of the session
+	// and a function that must be called to unsubscribe
+	Subscribe(sessionID string) (<-chan session.Session, func())
+}
+
+// hubBuffer is how many events a subscriber may lag behind before
+// it starts missing them. Every event carries the full session state,
+// so a subscriber that missed some catches up with the next one.
+const hubBuffer = 8
+
+// Hub fans session state changes out to the players connected to a session
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan session.Session]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[chan session.Session]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving every change of the session
+// and a function that must be called to unsubscribe
+func (h *Hub) Subscribe(sessionID string) (<-chan session.Session, func()) {
+	events := make(chan session.Session, hubBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[sessionID] == nil {
+		h.subscribers[sessionID] = make(map[chan session.Session]struct{})
+	}
+	h.subscribers[sessionID][events] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[sessionID], events)
+			if len(h.subscribers[sessionID]) == 0 {
+				delete(h.subscribers, sessionID)
+			}
+			h.mu.Unlock()
+			close(events)
+		})
+	}
+
+	return events, unsubscribe
+}
+
+// Publish sends the session state to its subscribers without blocking
+// on slow ones
+func (h *Hub) Publish(ctx context.Context, gameSession *session.Session) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for events := range h.subscribers[gameSession.ID] {
+		select {
+		case events <- *gameSession:
+		default:
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/domain/models/session"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// FastAnswerThreshold is faster than anybody can read a question and pick an option
+	FastAnswerThreshold = 200 * time.Millisecond
+
+	// AnswerGracePeriod covers network latency after the time limit
+	AnswerGracePeriod = time.Second
+
+	// Answers whose response times are all within TimingClusterWindow of each other
+	// form a cluster. A cluster is flagged when it has at least TimingClusterSize
+	// answers and answers given at random times would form one that large with
+	// a chance below TimingClusterSignificance, so big games are not flagged
+	// just for being crowded.
+	TimingClusterWindow       = 30 * time.Millisecond
+	TimingClusterSize         = 3
+	TimingClusterSignificance = 0.01
+
+	// timingClusterMinSpan is the least time the answers are taken to spread over,
+	// a few answers close together are not a crowd
+	timingClusterMinSpan = time.Second
+)
+
+var (
+	ErrQuestionClosed = errors.New("question is not open for answers")
+)
+
+// openQuestionIndex returns the index of the open question in the participants'
+// question order or an error if answers are not accepted now
+func openQuestionIndex(gameSession *session.Session, now time.Time) (int, error) {
+	switch {
+	case gameSession.Status&session.StatusFinished != 0:
+		return 0, ErrSessionFinished
+	case gameSession.Status&session.StatusActive == 0:
+		return 0, ErrQuestionClosed
+	case gameSession.QuestionOpenedAt == nil:
+		return 0, ErrQuestionClosed
+	case now.Before(*gameSession.QuestionOpenedAt):
+		return 0, ErrQuestionClosed
+	}
+
+	return gameSession.CurrentQuestionIndex, nil
+}
+
+// fastAnswerFlag flags an answer given faster than FastAnswerThreshold
+func fastAnswerFlag(gameSession *session.Session, answer *session.Answer) *session.Flag {
+	if time.Duration(answer.ResponseTimeMs)*time.Millisecond >= FastAnswerThreshold {
+		return nil
+	}
+
+	return &session.Flag{
+		ID:            uuid.NewString(),
+		SessionID:     gameSession.ID,
+		ParticipantID: answer.ParticipantID,
+		QuestionID:    answer.QuestionID,
+		Kind:          session.FlagFastAnswer,
+		Details:       fmt.Sprintf("answered in %d ms", answer.ResponseTimeMs),
+	}
+}
+
+// closeQuestion looks for suspicious patterns among the answers
+// to the question that is open now
+func (s *Service) closeQuestion(ctx context.Context, gameSession *session.Session) error {
+	if gameSession.QuestionOpenedAt == nil {
+		return nil
+	}
+
+	answers, err := s.sessionRepo.SessionAnswers(ctx, gameSession.ID, *gameSession.QuestionOpenedAt)
+	if err != nil {
+		return err
+	}
+
+	flags := timingClusterFlags(gameSession, answers)
+	if len(flags) == 0 {
+		return nil
+	}
+
+	return s.sessionRepo.SaveFlags(ctx, flags)
+}
+
+// timingClusterFlags flags answers given with nearly identical response times,
+// a sign of scripted or coordinated answering
+func timingClusterFlags(gameSession *session.Session, answers []*session.Answer) []session.Flag {
+	sorted := slices.Clone(answers)
+	slices.SortFunc(sorted, func(a, b *session.Answer) int {
+		return a.ResponseTimeMs - b.ResponseTimeMs
+	})
+
+	window := int(TimingClusterWindow / time.Millisecond)
+
+	var flags []session.Flag
+	if len(sorted) == 0 {
+		return flags
+	}
+
+	span := sorted[len(sorted)-1].ResponseTimeMs - sorted[0].ResponseTimeMs
+	threshold := clusterThreshold(len(sorted), max(span, int(timingClusterMinSpan/time.Millisecond)), window)
+
+	for start := 0; start < len(sorted); {
+		end := start + 1
+		for end < len(sorted) && sorted[end].ResponseTimeMs-sorted[start].ResponseTimeMs <= window {
+			end++
+		}
+
+		if cluster := sorted[start:end]; len(cluster) >= threshold {
+			for _, answer := range cluster {
+				flags = append(flags, session.Flag{
+					ID:            uuid.NewString(),
+					SessionID:     gameSession.ID,
+					ParticipantID: answer.ParticipantID,
+					QuestionID:    answer.QuestionID,
+					Kind:          session.FlagTimingCluster,
+					Details: fmt.Sprintf("%d answers between %d and %d ms",
+						len(cluster), cluster[0].ResponseTimeMs, cluster[len(cluster)-1].ResponseTimeMs),
+				})
+			}
+		}
+
+		start = end
+	}
+
+	return flags
+}
+
+// clusterThreshold is the smallest cluster of answers worth flagging among n answers
+// spread over span ms: answers at random times fall into each window as a Poisson
+// process, and every window of the span is a chance for a large cluster
+func clusterThreshold(n, span, window int) int {
+	windows := float64(span) / float64(window)
+	expected := float64(n) / windows
+
+	threshold := TimingClusterSize
+	for threshold <= n && poissonTail(expected, threshold)*windows >= TimingClusterSignificance {
+		threshold++
+	}
+	return threshold
+}
+
+// poissonTail is the chance of at least k events when lambda are expected
+func poissonTail(lambda float64, k int) float64 {
+	term := math.Exp(-lambda)
+	below := 0.0
+	for i := 0; i < k; i++ {
+		below += term
+		term *= lambda / float64(i+1)
+	}
+	return max(0, 1-below)
+}
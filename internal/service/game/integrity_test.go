@@ -0,0 +1,46 @@
+package game
+
+import (
+	"kahoot_bsu/internal/domain/models/session"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+)
+
+func TestTimingClusterFlags(t *testing.T) {
+	spread := func(n, over int, seed uint64) []int {
+		rnd := rand.New(rand.NewPCG(seed, 1))
+		times := make([]int, n)
+		for i := range times {
+			times[i] = rnd.IntN(over)
+		}
+		return times
+	}
+
+	tests := []struct {
+		name     string
+		times    []int
+		maxFlags int
+		minFlags int
+	}{
+		{name: "no answers", times: nil},
+		{name: "small game", times: []int{1200, 3400, 5100, 8000}},
+		{name: "crowded game", times: spread(300, 10000, 1)},
+		{name: "scripted answers", times: append(spread(30, 10000, 2), 5000, 5001, 5003, 5005, 5008), minFlags: 5, maxFlags: 7},
+		{name: "scripted answers in a crowded game", times: append(spread(300, 10000, 3), 4000, 4001, 4002, 4003, 4004, 4005, 4006, 4007, 4008, 4009), minFlags: 10, maxFlags: 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answers := make([]*session.Answer, len(tt.times))
+			for i, ms := range tt.times {
+				answers[i] = &session.Answer{ParticipantID: strconv.Itoa(i), ResponseTimeMs: ms}
+			}
+
+			flags := timingClusterFlags(&session.Session{ID: "s"}, answers)
+			if len(flags) < tt.minFlags || len(flags) > tt.maxFlags {
+				t.Errorf("got %d flags, want %d to %d", len(flags), tt.minFlags, tt.maxFlags)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package game
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DeepLink opens the bot and joins the session through /start
+func DeepLink(botUsername, joinCode string) string {
+	return "https://t.me/" + strings.TrimPrefix(botUsername, "@") + "?start=" + url.QueryEscape(joinCode)
+}
+
+// WebJoinURL opens the web app at baseURL with the join code filled in
+func WebJoinURL(baseURL, joinCode string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+
+	query := u.Query()
+	query.Set("join_code", joinCode)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"fmt"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"math/rand/v2"
+	"slices"
+)
+
+// DrawQuestions picks the questions of one draw: every question outside
+// of a pool, plus DrawCount questions of each pool honoring its minimums.
+// Questions keep the quiz order, pooled ones appear in the order drawn.
+func DrawQuestions(seed int64, questions []*question.Question, pools []quiz.Pool) ([]*question.Question, error) {
+	rnd := rand.New(rand.NewPCG(uint64(seed), 1))
+
+	byPool := make(map[string][]*question.Question)
+	for _, q := range questions {
+		if q.PoolID != "" {
+			byPool[q.PoolID] = append(byPool[q.PoolID], q)
+		}
+	}
+
+	drawn := make(map[string][]*question.Question, len(pools))
+	for _, pool := range pools {
+		picked, err := drawPool(rnd, pool, byPool[pool.ID])
+		if err != nil {
+			return nil, err
+		}
+		drawn[pool.ID] = picked
+	}
+
+	var result []*question.Question
+	for _, q := range questions {
+		if q.PoolID == "" {
+			result = append(result, q)
+			continue
+		}
+
+		// A pool takes the place of its first question in the quiz
+		if picked, ok := drawn[q.PoolID]; ok {
+			result = append(result, picked...)
+			delete(drawn, q.PoolID)
+		}
+	}
+
+	return result, nil
+}
+
+// drawPool picks pool.DrawCount questions from the bank of a pool
+func drawPool(rnd *rand.Rand, pool quiz.Pool, bank []*question.Question) ([]*question.Question, error) {
+	if len(bank) < pool.DrawCount {
+		return nil, fmt.Errorf("%w: pool %q has %d questions, %d requested", ErrPoolTooSmall, pool.Name, len(bank), pool.DrawCount)
+	}
+
+	remaining := slices.Clone(bank)
+	rnd.Shuffle(len(remaining), func(i, j int) {
+		remaining[i], remaining[j] = remaining[j], remaining[i]
+	})
+
+	// Map iteration order is random, sort to keep the draw reproducible
+	difficulties := make([]question.Difficulty, 0, len(pool.MinByDifficulty))
+	for difficulty := range pool.MinByDifficulty {
+		difficulties = append(difficulties, difficulty)
+	}
+	slices.Sort(difficulties)
+
+	picked := make([]*question.Question, 0, pool.DrawCount)
+	for _, difficulty := range difficulties {
+		need := pool.MinByDifficulty[difficulty]
+
+		for i := 0; i < len(remaining) && need > 0; {
+			if remaining[i].Difficulty != difficulty {
+				i++
+				continue
+			}
+			picked = append(picked, remaining[i])
+			remaining = slices.Delete(remaining, i, i+1)
+			need--
+		}
+
+		if need > 0 {
+			return nil, fmt.Errorf("%w: pool %q does not have %d %s questions", ErrPoolTooSmall, pool.Name, pool.MinByDifficulty[difficulty], difficulty)
+		}
+	}
+
+	if len(picked) > pool.DrawCount {
+		return nil, fmt.Errorf("pool %q requires more questions than it draws", pool.Name)
+	}
+
+	picked = append(picked, remaining[:pool.DrawCount-len(picked)]...)
+
+	// Minimums were picked first, mix them with the rest
+	rnd.Shuffle(len(picked), func(i, j int) {
+		picked[i], picked[j] = picked[j], picked[i]
+	})
+
+	return picked, nil
+}
+
+// questionIDs returns the IDs of questions in order
+func questionIDs(questions []*question.Question) []string {
+	ids := make([]string, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	return ids
+}
+
+// selectQuestions returns the questions with the given IDs in the order of ids
+func selectQuestions(questions []*question.Question, ids []string) []*question.Question {
+	byID := make(map[string]*question.Question, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+
+	selected := make([]*question.Question, 0, len(ids))
+	for _, id := range ids {
+		if q, ok := byID[id]; ok {
+			selected = append(selected, q)
+		}
+	}
+	return selected
+}
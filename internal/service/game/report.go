@@ -0,0 +1,72 @@
+package game
+
+import (
+	"context"
+	"kahoot_bsu/internal/domain/models/session"
+)
+
+// Report is the post-game summary of a session
+type Report struct {
+	Session      *session.Session    `json:"session"`
+	Participants []ParticipantReport `json:"participants"`
+}
+
+// ParticipantReport is the result of one participant with the answers
+// mapped to canonical question and option IDs
+type ParticipantReport struct {
+	Participant *session.Participant `json:"participant"`
+	MaxScore    int                  `json:"max_score"`
+	QuestionIDs []string             `json:"question_ids"`
+	Answers     []*session.Answer    `json:"answers"`
+	Flags       []session.Flag       `json:"flags,omitempty"`
+}
+
+// Report builds the post-game report of a session
+func (s *Service) Report(ctx context.Context, sessionID string) (*Report, error) {
+	gameSession, err := s.sessionRepo.Session(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	participants, err := s.sessionRepo.SessionParticipants(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := s.sessionRepo.SessionFlags(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	flagsByParticipant := make(map[string][]session.Flag)
+	for _, flag := range flags {
+		flagsByParticipant[flag.ParticipantID] = append(flagsByParticipant[flag.ParticipantID], flag)
+	}
+
+	report := &Report{
+		Session:      gameSession,
+		Participants: make([]ParticipantReport, 0, len(participants)),
+	}
+
+	for _, participant := range participants {
+		questions, err := s.participantQuestions(ctx, gameSession, participant)
+		if err != nil {
+			return nil, err
+		}
+
+		answers, err := s.sessionRepo.ParticipantAnswers(ctx, participant.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Participants = append(report.Participants, ParticipantReport{
+			Participant: participant,
+			MaxScore:    MaxScore(questions),
+			QuestionIDs: questionIDs(ParticipantQuestions(gameSession.Settings, participant.Seed, questions)),
+			Answers:     answers,
+			Flags:       flagsByParticipant[participant.ID],
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,24 @@
+package game
+
+import "kahoot_bsu/internal/domain/models/question"
+
+// Score returns the points awarded for an answer to a question
+func Score(q *question.Question, isCorrect bool) int {
+	if !isCorrect {
+		return 0
+	}
+	return q.Points * q.PointsMode.Multiplier()
+}
+
+// MaxScore returns the most points a participant can get for the questions
+// without bonus ones, which can only add to the score
+func MaxScore(questions []*question.Question) int {
+	total := 0
+	for _, q := range questions {
+		if q.IsBonus {
+			continue
+		}
+		total += Score(q, true)
+	}
+	return total
+}
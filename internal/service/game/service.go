@@ -0,0 +1,415 @@
+package game
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/domain/models/session"
+	"kahoot_bsu/internal/ports"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSessionFinished = errors.New("game session is finished")
+	ErrUnknownQuestion = errors.New("question does not belong to the session quiz")
+	ErrUnknownOption   = errors.New("option does not belong to the question")
+	ErrPoolTooSmall    = errors.New("pool does not have enough questions to draw")
+)
+
+// Service runs game sessions: hosting, joining and grading answers
+type Service struct {
+	sessionRepo       session.Repository
+	questionRepo      question.Repository
+	poolRepo          quiz.PoolRepository
+	joinCodeGenerator ports.JoinCodeGenerator
+	events            Events
+	tx                ports.Transactor
+}
+
+type Option func(*Service)
+
+// noTx runs functions without a transaction, for repositories that have none
+type noTx struct{}
+
+func (noTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// WithTransactor stores the writes that belong together, like a participant
+// and their draw, in one transaction of the repositories
+func WithTransactor(tx ports.Transactor) Option {
+	return func(s *Service) {
+		s.tx = tx
+	}
+}
+
+// WithEvents shares the session changes through events instead of
+// a Hub of this process
+func WithEvents(events Events) Option {
+	return func(s *Service) {
+		s.events = events
+	}
+}
+
+func NewService(
+	sessionRepo session.Repository,
+	questionRepo question.Repository,
+	poolRepo quiz.PoolRepository,
+	joinCodeGenerator ports.JoinCodeGenerator,
+	opts ...Option,
+) *Service {
+	s := &Service{
+		sessionRepo:       sessionRepo,
+		questionRepo:      questionRepo,
+		poolRepo:          poolRepo,
+		joinCodeGenerator: joinCodeGenerator,
+		events:            NewHub(),
+		tx:                noTx{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Subscribe streams the state changes of a session to a connected player
+func (s *Service) Subscribe(sessionID string) (<-chan session.Session, func()) {
+	return s.events.Subscribe(sessionID)
+}
+
+// CreateSession opens a new game session for a quiz
+func (s *Service) CreateSession(ctx context.Context, quizID string, hostID int64, settings session.Settings) (*session.Session, error) {
+	joinCode, err := s.joinCodeGenerator.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate join code: %w", err)
+	}
+
+	if settings.PoolDraw == "" {
+		settings.PoolDraw = session.DrawPerSession
+	}
+
+	seed, err := newSeed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session seed: %w", err)
+	}
+
+	// Draw before the session is stored, so pools that cannot be drawn leave
+	// no session behind. Per-participant draws have the same pool sizes,
+	// the session draw checks them.
+	drawn, err := s.drawIDs(ctx, quizID, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	gameSession := &session.Session{
+		ID:       uuid.NewString(),
+		QuizID:   quizID,
+		HostID:   hostID,
+		JoinCode: joinCode,
+		Status:   session.StatusWaiting,
+		Settings: settings,
+	}
+
+	if err := s.sessionRepo.Create(ctx, gameSession); err != nil {
+		return nil, err
+	}
+
+	if settings.PoolDraw == session.DrawPerSession && drawn != nil {
+		if err := s.sessionRepo.SaveDraw(ctx, gameSession.ID, "", drawn); err != nil {
+			return nil, err
+		}
+	}
+
+	return gameSession, nil
+}
+
+// Join registers a participant in the session with the given join code
+func (s *Service) Join(ctx context.Context, joinCode string, userID *int64, login string) (*session.Participant, error) {
+	gameSession, err := s.joinableSession(ctx, joinCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.addParticipant(ctx, gameSession, &session.Participant{
+		UserID: userID,
+		Login:  login,
+	})
+}
+
+// JoinTelegram registers a Telegram user in the session with the given join code,
+// a user who has already joined gets their participant back
+func (s *Service) JoinTelegram(ctx context.Context, joinCode string, telegramID int64, userID *int64, login string) (*session.Participant, error) {
+	gameSession, err := s.joinableSession(ctx, joinCode)
+	if err != nil {
+		return nil, err
+	}
+
+	participant, err := s.sessionRepo.ParticipantByTelegramID(ctx, gameSession.ID, telegramID)
+	var notFoundErr session.ParticipantNotFoundError
+	switch {
+	case err == nil:
+		return participant, nil
+	case !errors.As(err, &notFoundErr):
+		return nil, err
+	}
+
+	participant, err = s.addParticipant(ctx, gameSession, &session.Participant{
+		UserID:     userID,
+		TelegramID: &telegramID,
+		Login:      login,
+	})
+	if errors.Is(err, session.ErrDuplicateParticipant) {
+		// A concurrent request of the same user joined first
+		return s.sessionRepo.ParticipantByTelegramID(ctx, gameSession.ID, telegramID)
+	}
+	return participant, err
+}
+
+// joinableSession returns the session with the join code unless it is finished
+func (s *Service) joinableSession(ctx context.Context, joinCode string) (*session.Session, error) {
+	gameSession, err := s.sessionRepo.SessionByJoinCode(ctx, joinCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if gameSession.Status&session.StatusFinished != 0 {
+		return nil, ErrSessionFinished
+	}
+
+	return gameSession, nil
+}
+
+// addParticipant stores a new participant of the session with their draw
+func (s *Service) addParticipant(ctx context.Context, gameSession *session.Session, participant *session.Participant) (*session.Participant, error) {
+	seed, err := newSeed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate participant seed: %w", err)
+	}
+
+	participant.ID = uuid.NewString()
+	participant.SessionID = gameSession.ID
+	participant.Seed = seed
+
+	// Draw before the participant is stored, a failed draw leaves no
+	// participant without questions behind
+	var drawn []string
+	if gameSession.Settings.PoolDraw == session.DrawPerParticipant {
+		drawn, err = s.drawIDs(ctx, gameSession.QuizID, participant.Seed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.AddParticipant(ctx, participant); err != nil {
+			return err
+		}
+		if drawn == nil {
+			return nil
+		}
+		return s.sessionRepo.SaveDraw(ctx, gameSession.ID, participant.ID, drawn)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return participant, nil
+}
+
+// ParticipantQuestions returns the session questions in the participant's order
+func (s *Service) ParticipantQuestions(ctx context.Context, participantID string) ([]*question.Question, error) {
+	participant, gameSession, err := s.participantSession(ctx, participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.participantQuestions(ctx, gameSession, participant)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParticipantQuestions(gameSession.Settings, participant.Seed, questions), nil
+}
+
+// SessionQuestions returns the questions of the session draw in canonical order,
+// the order every participant plays when nothing is shuffled
+func (s *Service) SessionQuestions(ctx context.Context, sessionID string) ([]*question.Question, error) {
+	gameSession, err := s.sessionRepo.Session(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.questionRepo.QuizQuestions(ctx, gameSession.QuizID)
+	if err != nil {
+		return nil, err
+	}
+
+	drawn, err := s.sessionRepo.Draw(ctx, gameSession.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if drawn != nil {
+		return selectQuestions(questions, drawn), nil
+	}
+
+	return questions, nil
+}
+
+// SubmitAnswer grades an answer to the open question against the canonical
+// options and stores it. The response time is measured on the server.
+func (s *Service) SubmitAnswer(
+	ctx context.Context,
+	participantID string,
+	questionID string,
+	optionID string,
+) (*session.Answer, error) {
+	now := time.Now()
+
+	participant, gameSession, err := s.participantSession(ctx, participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := openQuestionIndex(gameSession, now)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.ParticipantQuestions(ctx, participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the question the participant is shown now can be answered
+	if !slices.ContainsFunc(questions, func(q *question.Question) bool { return q.ID == questionID }) {
+		return nil, ErrUnknownQuestion
+	}
+
+	if index >= len(questions) || questions[index].ID != questionID {
+		return nil, ErrQuestionClosed
+	}
+
+	q := questions[index]
+
+	responseTime := now.Sub(*gameSession.QuestionOpenedAt)
+	if q.TimeLimit > 0 && responseTime > time.Duration(q.TimeLimit)*time.Second+AnswerGracePeriod {
+		return nil, ErrQuestionClosed
+	}
+
+	var option *question.Option
+	for i := range q.Options {
+		if q.Options[i].ID == optionID {
+			option = &q.Options[i]
+			break
+		}
+	}
+
+	if option == nil {
+		return nil, ErrUnknownOption
+	}
+
+	answer := &session.Answer{
+		ID:             uuid.NewString(),
+		ParticipantID:  participant.ID,
+		QuestionID:     q.ID,
+		OptionID:       option.ID,
+		IsCorrect:      option.IsCorrect,
+		ResponseTimeMs: int(responseTime.Milliseconds()),
+		PointsAwarded:  Score(q, option.IsCorrect),
+	}
+
+	if err := s.sessionRepo.SaveAnswer(ctx, answer); err != nil {
+		return nil, err
+	}
+
+	if flag := fastAnswerFlag(gameSession, answer); flag != nil {
+		if err := s.sessionRepo.SaveFlags(ctx, []session.Flag{*flag}); err != nil {
+			return nil, err
+		}
+	}
+
+	return answer, nil
+}
+
+// drawIDs draws the questions of a quiz with pools, nil for a quiz without them
+func (s *Service) drawIDs(ctx context.Context, quizID string, seed int64) ([]string, error) {
+	pools, err := s.poolRepo.QuizPools(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pools) == 0 {
+		return nil, nil
+	}
+
+	questions, err := s.questionRepo.QuizQuestions(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	drawn, err := DrawQuestions(seed, questions, pools)
+	if err != nil {
+		return nil, err
+	}
+
+	return questionIDs(drawn), nil
+}
+
+// participantQuestions returns the questions a participant plays in canonical
+// order: their own draw, the session draw, or the whole quiz without pools
+func (s *Service) participantQuestions(
+	ctx context.Context,
+	gameSession *session.Session,
+	participant *session.Participant,
+) ([]*question.Question, error) {
+	questions, err := s.questionRepo.QuizQuestions(ctx, gameSession.QuizID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, participantID := range []string{participant.ID, ""} {
+		drawn, err := s.sessionRepo.Draw(ctx, gameSession.ID, participantID)
+		if err != nil {
+			return nil, err
+		}
+
+		if drawn != nil {
+			return selectQuestions(questions, drawn), nil
+		}
+	}
+
+	return questions, nil
+}
+
+// participantSession loads a participant together with their game session
+func (s *Service) participantSession(ctx context.Context, participantID string) (*session.Participant, *session.Session, error) {
+	participant, err := s.sessionRepo.Participant(ctx, participantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gameSession, err := s.sessionRepo.Session(ctx, participant.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return participant, gameSession, nil
+}
+
+func newSeed() (int64, error) {
+	var buffer [8]byte
+	if _, err := rand.Read(buffer[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(buffer[:])), nil
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/quiz"
+	"kahoot_bsu/internal/domain/models/session"
+	"testing"
+)
+
+// joinSessions is a session.Repository with one waiting session that
+// records the participants added to it
+type joinSessions struct {
+	session.Repository
+
+	current      session.Session
+	participants []*session.Participant
+	draws        map[string][]string
+}
+
+func (r *joinSessions) SessionByJoinCode(_ context.Context, _ string) (*session.Session, error) {
+	observed := r.current
+	return &observed, nil
+}
+
+func (r *joinSessions) AddParticipant(_ context.Context, p *session.Participant) error {
+	r.participants = append(r.participants, p)
+	return nil
+}
+
+func (r *joinSessions) ParticipantByTelegramID(_ context.Context, _ string, telegramID int64) (*session.Participant, error) {
+	for _, p := range r.participants {
+		if p.TelegramID != nil && *p.TelegramID == telegramID {
+			return p, nil
+		}
+	}
+	return nil, session.ParticipantNotFoundError{}
+}
+
+func (r *joinSessions) SaveDraw(_ context.Context, _ string, participantID string, questionIDs []string) error {
+	if r.draws == nil {
+		r.draws = make(map[string][]string)
+	}
+	r.draws[participantID] = questionIDs
+	return nil
+}
+
+type fixedPools []quiz.Pool
+
+func (p fixedPools) QuizPools(_ context.Context, _ string) ([]quiz.Pool, error) { return p, nil }
+
+func (fixedPools) SavePools(_ context.Context, _ string, _ []quiz.Pool) error { return nil }
+
+func TestJoinDrawsPerParticipant(t *testing.T) {
+	questions := fixedQuestions{questions: []*question.Question{
+		{ID: "1", PoolID: "p"}, {ID: "2", PoolID: "p"}, {ID: "3"},
+	}}
+
+	tests := []struct {
+		name         string
+		drawCount    int
+		wantErr      error
+		participants int
+	}{
+		{name: "drawn", drawCount: 1, participants: 1},
+		{name: "pool too small", drawCount: 3, wantErr: ErrPoolTooSmall},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &joinSessions{current: session.Session{
+				ID:       "s",
+				QuizID:   "q",
+				Status:   session.StatusWaiting,
+				Settings: session.Settings{PoolDraw: session.DrawPerParticipant},
+			}}
+			pools := fixedPools{{ID: "p", QuizID: "q", Name: "p", DrawCount: tt.drawCount}}
+			s := NewService(sessions, questions, pools, nil)
+
+			userID := int64(1)
+			participant, err := s.Join(context.Background(), "123456", &userID, "user")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Join() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(sessions.participants) != tt.participants {
+				t.Fatalf("Join() added %d participants, want %d", len(sessions.participants), tt.participants)
+			}
+			if participant != nil && len(sessions.draws[participant.ID]) != 2 {
+				t.Errorf("Join() saved draw %v, want 2 questions", sessions.draws[participant.ID])
+			}
+		})
+	}
+}
+
+func TestJoinTelegramReturnsExistingParticipant(t *testing.T) {
+	sessions := &joinSessions{current: session.Session{ID: "s", QuizID: "q", Status: session.StatusWaiting}}
+	s := NewService(sessions, fixedQuestions{}, noPools{}, nil)
+
+	first, err := s.JoinTelegram(context.Background(), "123456", 42, nil, "user")
+	if err != nil {
+		t.Fatalf("JoinTelegram() error = %v", err)
+	}
+	second, err := s.JoinTelegram(context.Background(), "123456", 42, nil, "user")
+	if err != nil {
+		t.Fatalf("JoinTelegram() error = %v", err)
+	}
+
+	if second.ID != first.ID {
+		t.Errorf("JoinTelegram() = participant %s, want the existing %s", second.ID, first.ID)
+	}
+	if len(sessions.participants) != 1 {
+		t.Errorf("JoinTelegram() added %d participants, want 1", len(sessions.participants))
+	}
+}
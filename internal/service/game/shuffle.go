@@ -0,0 +1,55 @@
+package game
+
+import (
+	"hash/fnv"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/domain/models/session"
+	"math/rand/v2"
+)
+
+// ParticipantQuestions returns the questions in the order a participant sees them.
+// The order is derived from the participant's seed only, so it can be rebuilt for
+// grading, reports and replays. Option IDs are kept, answers always refer to
+// the canonical options. The input slice is not modified.
+func ParticipantQuestions(settings session.Settings, seed int64, questions []*question.Question) []*question.Question {
+	view := make([]*question.Question, len(questions))
+	for i, q := range questions {
+		copied := *q
+		copied.Options = append([]question.Option(nil), q.Options...)
+		view[i] = &copied
+	}
+
+	if settings.ShuffleQuestions {
+		rnd := rand.New(rand.NewPCG(uint64(seed), 0))
+		rnd.Shuffle(len(view), func(i, j int) {
+			view[i], view[j] = view[j], view[i]
+		})
+	}
+
+	if settings.ShuffleOptions {
+		for _, q := range view {
+			// Each question gets its own stream, so adding a question to the quiz
+			// does not change the option order of the others
+			rnd := rand.New(rand.NewPCG(uint64(seed), hashID(q.ID)))
+			rnd.Shuffle(len(q.Options), func(i, j int) {
+				q.Options[i], q.Options[j] = q.Options[j], q.Options[i]
+			})
+		}
+	}
+
+	return view
+}
+
+// OptionAt returns the canonical option shown at a display position, or nil
+func OptionAt(q *question.Question, position int) *question.Option {
+	if position < 0 || position >= len(q.Options) {
+		return nil
+	}
+	return &q.Options[position]
+}
+
+func hashID(id string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return h.Sum64()
+}
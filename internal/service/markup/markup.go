@@ -0,0 +1,279 @@
+package markup
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Limits for the restricted markup dialect
+const (
+	MaxTextLength    = 4096
+	MaxFormulaLength = 512
+)
+
+// forbiddenCommands are LaTeX commands that can load resources, define macros
+// or inject markup into the rendered page
+var forbiddenCommands = []string{
+	`\href`,
+	`\url`,
+	`\includegraphics`,
+	`\input`,
+	`\include`,
+	`\def`,
+	`\gdef`,
+	`\edef`,
+	`\xdef`,
+	`\let`,
+	`\newcommand`,
+	`\renewcommand`,
+	`\providecommand`,
+	`\write`,
+	`\immediate`,
+	`\catcode`,
+	`\htmlClass`,
+	`\htmlId`,
+	`\htmlStyle`,
+	`\htmlData`,
+	`\class`,
+	`\cssId`,
+	`\style`,
+}
+
+// InvalidMarkupError is returned when text does not follow the markup dialect
+type InvalidMarkupError struct {
+	Reason string
+}
+
+func (e InvalidMarkupError) Error() string {
+	return fmt.Sprintf("invalid markup: %s", e.Reason)
+}
+
+type segmentKind int
+
+const (
+	segmentText segmentKind = iota
+	segmentBold
+	segmentItalic
+	segmentCode
+	segmentFormula
+)
+
+// segment is a run of text with a single kind of formatting
+type segment struct {
+	kind segmentKind
+	text string
+}
+
+// Sanitize normalizes text and validates it against the markup dialect:
+// **bold**, *italic* or _italic_, `code` and inline $formula$.
+// Special characters can be escaped with a backslash.
+func Sanitize(text string) (string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	text = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+
+	// Collapse runs of blank lines into a single paragraph break
+	for strings.Contains(text, "\n\n\n") {
+		text = strings.ReplaceAll(text, "\n\n\n", "\n\n")
+	}
+
+	text = strings.TrimSpace(text)
+
+	if len([]rune(text)) > MaxTextLength {
+		return "", InvalidMarkupError{Reason: fmt.Sprintf("text is longer than %d characters", MaxTextLength)}
+	}
+
+	if _, err := parse(text); err != nil {
+		return "", err
+	}
+
+	return text, nil
+}
+
+// Formulas returns the LaTeX source of every inline formula in text
+func Formulas(text string) ([]string, error) {
+	segments, err := parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var formulas []string
+	for _, s := range segments {
+		if s.kind == segmentFormula {
+			formulas = append(formulas, s.text)
+		}
+	}
+
+	return formulas, nil
+}
+
+// parse splits text into formatted segments
+func parse(text string) ([]segment, error) {
+	var (
+		segments []segment
+		plain    strings.Builder
+	)
+
+	flush := func() {
+		if plain.Len() > 0 {
+			segments = append(segments, segment{kind: segmentText, text: plain.String()})
+			plain.Reset()
+		}
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '\\' && i+1 < len(runes) && isEscapable(runes[i+1]):
+			plain.WriteRune(runes[i+1])
+			i++
+
+		case r == '$':
+			end := closing(runes, i+1, "$")
+			if end < 0 {
+				return nil, InvalidMarkupError{Reason: "unclosed formula"}
+			}
+
+			formula := strings.TrimSpace(string(runes[i+1 : end]))
+			if err := validateFormula(formula); err != nil {
+				return nil, err
+			}
+
+			flush()
+			segments = append(segments, segment{kind: segmentFormula, text: formula})
+			i = end
+
+		case r == '`':
+			end := closing(runes, i+1, "`")
+			if end < 0 {
+				return nil, InvalidMarkupError{Reason: "unclosed code span"}
+			}
+
+			flush()
+			segments = append(segments, segment{kind: segmentCode, text: string(runes[i+1 : end])})
+			i = end
+
+		case r == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			end := closing(runes, i+2, "**")
+			if end < 0 {
+				return nil, InvalidMarkupError{Reason: "unclosed bold text"}
+			}
+
+			flush()
+			segments = append(segments, segment{kind: segmentBold, text: unescape(runes[i+2 : end])})
+			i = end + 1
+
+		case r == '*' || r == '_':
+			end := closing(runes, i+1, string(r))
+			if end < 0 || end == i+1 || !opensEmphasis(runes, i) {
+				// A lone marker is kept as is, e.g. "2 * 3" or "snake_case"
+				plain.WriteRune(r)
+				continue
+			}
+
+			flush()
+			segments = append(segments, segment{kind: segmentItalic, text: unescape(runes[i+1 : end])})
+			i = end
+
+		default:
+			plain.WriteRune(r)
+		}
+	}
+
+	flush()
+
+	return segments, nil
+}
+
+// closing returns the index of the next unescaped marker starting at from, or -1
+func closing(runes []rune, from int, marker string) int {
+	m := []rune(marker)
+
+	for i := from; i+len(m) <= len(runes); i++ {
+		if runes[i] == '\\' {
+			i++
+			continue
+		}
+		if runes[i] == '\n' && m[0] != '$' {
+			// Inline formatting does not span lines
+			return -1
+		}
+		if string(runes[i:i+len(m)]) == marker {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// unescape drops the backslash in front of escaped markup characters
+func unescape(runes []rune) string {
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) && isEscapable(runes[i+1]) {
+			i++
+		}
+		b.WriteRune(runes[i])
+	}
+	return b.String()
+}
+
+// opensEmphasis reports whether the marker at i can start italic text:
+// it must be followed by a non-space and, for "_", not be inside a word
+func opensEmphasis(runes []rune, i int) bool {
+	if i+1 >= len(runes) || unicode.IsSpace(runes[i+1]) {
+		return false
+	}
+
+	if runes[i] == '_' && i > 0 && (unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+		return false
+	}
+
+	return true
+}
+
+func isEscapable(r rune) bool {
+	return strings.ContainsRune(`\$*_`+"`", r)
+}
+
+// validateFormula checks a formula against length and command restrictions
+func validateFormula(formula string) error {
+	if formula == "" {
+		return InvalidMarkupError{Reason: "empty formula"}
+	}
+
+	if len([]rune(formula)) > MaxFormulaLength {
+		return InvalidMarkupError{Reason: fmt.Sprintf("formula is longer than %d characters", MaxFormulaLength)}
+	}
+
+	for _, command := range forbiddenCommands {
+		for offset := 0; ; {
+			idx := strings.Index(formula[offset:], command)
+			if idx < 0 {
+				break
+			}
+
+			// Only reject whole commands, so \letter is not mistaken for \let
+			next := offset + idx + len(command)
+			if next >= len(formula) || !unicode.IsLetter(rune(formula[next])) {
+				return InvalidMarkupError{Reason: fmt.Sprintf("command %s is not allowed in formulas", command)}
+			}
+
+			offset = next
+		}
+	}
+
+	return nil
+}
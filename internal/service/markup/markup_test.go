@@ -0,0 +1,109 @@
+package markup
+
+import (
+	"errors"
+	"kahoot_bsu/internal/ports"
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", text: "  2 + 2  ", want: "2 + 2"},
+		{name: "formatting", text: "**bold** *italic* _italic_ `code` $x^2$", want: "**bold** *italic* _italic_ `code` $x^2$"},
+		{name: "line endings", text: "a\r\nb\rc", want: "a\nb\nc"},
+		{name: "blank lines", text: "a\n\n\n\n\nb", want: "a\n\nb"},
+		{name: "control characters", text: "a\x00b\x1bc", want: "abc"},
+		{name: "escaped dollar", text: `costs \$5`, want: `costs \$5`},
+		{name: "snake case", text: "snake_case_name", want: "snake_case_name"},
+		{name: "unclosed formula", text: "$x", wantErr: true},
+		{name: "empty formula", text: "$ $", wantErr: true},
+		{name: "href", text: `$\href{http://evil}{x}$`, wantErr: true},
+		{name: "macro", text: `$\def\x{1}$`, wantErr: true},
+		{name: "html class", text: `$\htmlClass{x}{y}$`, wantErr: true},
+		{name: "command prefix", text: `$\left( \leftarrow \right)$`, want: `$\left( \leftarrow \right)$`},
+		{name: "long text", text: strings.Repeat("a", MaxTextLength+1), wantErr: true},
+		{name: "long formula", text: "$" + strings.Repeat("x", MaxFormulaLength+1) + "$", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Sanitize(tt.text)
+			if tt.wantErr {
+				var markupErr InvalidMarkupError
+				if !errors.As(err, &markupErr) {
+					t.Fatalf("Sanitize(%q) error = %v, want InvalidMarkupError", tt.text, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Sanitize(%q) error = %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "escapes html", text: `<script>alert("x")</script>`, want: "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;"},
+		{name: "formatting", text: "**a** *b* `c`", want: "<strong>a</strong> <em>b</em> <code>c</code>"},
+		{name: "formula", text: "$a<b$", want: `<span class="math">\(a&lt;b\)</span>`},
+		{name: "line breaks", text: "a\nb", want: "a<br>b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderHTML(tt.text)
+			if err != nil {
+				t.Fatalf("RenderHTML(%q) error = %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderHTML(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeRenderer struct{}
+
+func (fakeRenderer) ImageURL(formula string) (string, error) {
+	return `https://img/?` + formula, nil
+}
+
+func TestRenderTelegramHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		renderer ports.FormulaRenderer
+		want     string
+	}{
+		{name: "escapes html", text: "<b>x</b> & y", want: "&lt;b&gt;x&lt;/b&gt; &amp; y"},
+		{name: "formatting", text: "**a** _b_", want: "<b>a</b> <i>b</i>"},
+		{name: "formula without renderer", text: "$x$", want: "<code>x</code>"},
+		{name: "formula link", text: `$a"b$`, renderer: fakeRenderer{}, want: `<a href="https://img/?a&#34;b">a&#34;b</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTelegramHTML(tt.text, tt.renderer)
+			if err != nil {
+				t.Fatalf("RenderTelegramHTML(%q) error = %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTelegramHTML(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
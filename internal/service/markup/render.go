@@ -0,0 +1,93 @@
+package markup
+
+import (
+	"fmt"
+	"html"
+	"kahoot_bsu/internal/ports"
+	"strings"
+)
+
+// RenderHTML renders text to HTML for the web client.
+// Formulas are wrapped in \( \) delimiters for client-side rendering.
+func RenderHTML(text string) (string, error) {
+	segments, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, s := range segments {
+		escaped := html.EscapeString(s.text)
+
+		switch s.kind {
+		case segmentBold:
+			b.WriteString("<strong>" + escaped + "</strong>")
+		case segmentItalic:
+			b.WriteString("<em>" + escaped + "</em>")
+		case segmentCode:
+			b.WriteString("<code>" + escaped + "</code>")
+		case segmentFormula:
+			b.WriteString(`<span class="math">\(` + escaped + `\)</span>`)
+		default:
+			b.WriteString(strings.ReplaceAll(escaped, "\n", "<br>"))
+		}
+	}
+
+	return b.String(), nil
+}
+
+// RenderTelegramHTML renders text to the HTML subset supported by the Telegram Bot API.
+// Telegram cannot typeset LaTeX, so every formula links to its image
+// from renderer. A nil renderer leaves formulas as code.
+func RenderTelegramHTML(text string, renderer ports.FormulaRenderer) (string, error) {
+	segments, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, s := range segments {
+		escaped := html.EscapeString(s.text)
+
+		switch s.kind {
+		case segmentBold:
+			b.WriteString("<b>" + escaped + "</b>")
+		case segmentItalic:
+			b.WriteString("<i>" + escaped + "</i>")
+		case segmentCode:
+			b.WriteString("<code>" + escaped + "</code>")
+		case segmentFormula:
+			if renderer == nil {
+				b.WriteString("<code>" + escaped + "</code>")
+				continue
+			}
+
+			imageURL, err := renderer.ImageURL(s.text)
+			if err != nil {
+				return "", fmt.Errorf("failed to render formula: %w", err)
+			}
+
+			b.WriteString(`<a href="` + html.EscapeString(imageURL) + `">` + escaped + "</a>")
+		default:
+			b.WriteString(escaped)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// PlainText strips the markup from text for places without formatting,
+// such as button labels. Formulas keep their LaTeX source.
+func PlainText(text string) (string, error) {
+	segments, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteString(s.text)
+	}
+
+	return b.String(), nil
+}
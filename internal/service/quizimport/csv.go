@@ -0,0 +1,93 @@
+package quizimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"kahoot_bsu/internal/domain/models/question"
+	"strconv"
+	"strings"
+)
+
+// parseCSV reads the rows "question,time_limit,points,correct,option 1,option 2,...",
+// correct is the number of the correct option, an empty time limit or points take
+// the defaults. A first row starting with "question" is a header.
+func parseCSV(data []byte) (*Result, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Excel separates the fields with semicolons in many locales
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	result := &Result{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				result.Errors = append(result.Errors, LineError{Line: parseErr.Line, Message: parseErr.Err.Error()})
+				return result, nil
+			}
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		if line == 1 && strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(record[0]), "\uFEFF"), "question") {
+			continue
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		if len(record) < 4 {
+			result.Errors = append(result.Errors, LineError{Line: line, Message: "нужны столбцы: вопрос, время, очки, номер правильного варианта и варианты"})
+			continue
+		}
+
+		q := question.Question{Text: strings.TrimSpace(strings.TrimPrefix(record[0], "\uFEFF"))}
+
+		var problems []string
+		if q.TimeLimit, err = optionalInt(record[1], DefaultTimeLimit); err != nil {
+			problems = append(problems, "время не число")
+		}
+		if q.Points, err = optionalInt(record[2], DefaultPoints); err != nil {
+			problems = append(problems, "очки не число")
+		}
+		correct, err := strconv.Atoi(strings.TrimSpace(record[3]))
+		if err != nil {
+			problems = append(problems, "номер правильного варианта не число")
+		}
+		if len(problems) > 0 {
+			result.Errors = append(result.Errors, LineError{Line: line, Message: strings.Join(problems, "; ")})
+			continue
+		}
+
+		for i, text := range record[4:] {
+			if text = strings.TrimSpace(text); text == "" && i >= MinOptions {
+				// Trailing empty cells of a spreadsheet
+				continue
+			}
+			q.Options = append(q.Options, question.Option{Text: strings.TrimSpace(text), IsCorrect: i+1 == correct})
+		}
+
+		result.add(line, q)
+	}
+
+	return result, nil
+}
+
+func optionalInt(value string, fallback int) (int, error) {
+	if value = strings.TrimSpace(value); value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,175 @@
+package quizimport
+
+import (
+	"bytes"
+	"kahoot_bsu/internal/domain/models/question"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// weightPattern is the weight of a GIFT answer, "%50%"
+var weightPattern = regexp.MustCompile(`^%(-?[0-9.]+)%`)
+
+// parseGIFT reads multiple choice and true/false questions of the Moodle GIFT format:
+//
+//	// comment
+//	::Title::Question text {
+//	=correct answer
+//	~wrong answer#feedback
+//	}
+//
+// Questions are separated by blank lines, other question types are reported as errors.
+func parseGIFT(data []byte) (*Result, error) {
+	result := &Result{}
+
+	var (
+		block []string
+		start int
+	)
+	flush := func() {
+		if len(block) > 0 {
+			parseGIFTQuestion(result, start, strings.Join(block, "\n"))
+		}
+		block = nil
+	}
+
+	lines := strings.Split(strings.TrimPrefix(string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))), "\uFEFF"), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "//"), strings.HasPrefix(trimmed, "$CATEGORY:"):
+			// Comments and categories
+		default:
+			if len(block) == 0 {
+				start = i + 1
+			}
+			block = append(block, line)
+		}
+	}
+	flush()
+
+	return result, nil
+}
+
+func parseGIFTQuestion(result *Result, line int, text string) {
+	open := indexUnescaped(text, '{')
+	closing := lastIndexUnescaped(text, '}')
+	if open < 0 || closing < open {
+		result.Errors = append(result.Errors, LineError{Line: line, Message: "нет вариантов ответа в фигурных скобках"})
+		return
+	}
+
+	stem := strings.TrimSpace(text[:open])
+	// The name of the question is not shown to the players
+	if strings.HasPrefix(stem, "::") {
+		if end := strings.Index(stem[2:], "::"); end >= 0 {
+			stem = strings.TrimSpace(stem[end+4:])
+		}
+	}
+	// Markup of the text, "[html]" or "[markdown]"
+	if strings.HasPrefix(stem, "[") {
+		if end := strings.Index(stem, "]"); end >= 0 {
+			stem = strings.TrimSpace(stem[end+1:])
+		}
+	}
+	// A question with text after the answers, "Cats have {=four ~six} legs"
+	if rest := strings.TrimSpace(text[closing+1:]); rest != "" {
+		stem += " _____ " + rest
+	}
+
+	q := question.Question{Text: unescapeGIFT(stem), Points: DefaultPoints}
+
+	answers := strings.TrimSpace(text[open+1 : closing])
+	switch strings.ToUpper(answers) {
+	case "T", "TRUE":
+		q.Options = []question.Option{{Text: "Верно", IsCorrect: true}, {Text: "Неверно"}}
+		result.add(line, q)
+		return
+	case "F", "FALSE":
+		q.Options = []question.Option{{Text: "Верно"}, {Text: "Неверно", IsCorrect: true}}
+		result.add(line, q)
+		return
+	}
+
+	if strings.HasPrefix(answers, "#") || strings.Contains(answers, "->") || !strings.Contains(answers, "~") {
+		result.Errors = append(result.Errors, LineError{Line: line, Message: "поддерживаются только вопросы с выбором ответа и верно/неверно"})
+		return
+	}
+
+	for _, answer := range splitGIFTAnswers(answers) {
+		correct := answer[0] == '='
+		answer = strings.TrimSpace(answer[1:])
+
+		if match := weightPattern.FindStringSubmatch(answer); match != nil {
+			weight, _ := strconv.ParseFloat(match[1], 64)
+			correct = weight >= 100
+			answer = strings.TrimSpace(answer[len(match[0]):])
+		}
+		if feedback := indexUnescaped(answer, '#'); feedback >= 0 {
+			answer = strings.TrimSpace(answer[:feedback])
+		}
+
+		q.Options = append(q.Options, question.Option{Text: unescapeGIFT(answer), IsCorrect: correct})
+	}
+
+	result.add(line, q)
+}
+
+// splitGIFTAnswers splits the answers at the unescaped = and ~ marks
+func splitGIFTAnswers(answers string) []string {
+	var (
+		result []string
+		start  = -1
+	)
+	for i := 0; i < len(answers); i++ {
+		switch answers[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			if start >= 0 {
+				result = append(result, answers[start:i])
+			}
+			start = i
+		}
+	}
+	if start >= 0 {
+		result = append(result, answers[start:])
+	}
+	return result
+}
+
+func indexUnescaped(text string, char byte) int {
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case char:
+			return i
+		}
+	}
+	return -1
+}
+
+// lastIndexUnescaped is indexUnescaped from the end, an escaped char such as
+// "\}" in the text after the answers is not taken for the closing brace
+func lastIndexUnescaped(text string, char byte) int {
+	last := -1
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case char:
+			last = i
+		}
+	}
+	return last
+}
+
+var giftEscapes = strings.NewReplacer(`\=`, "=", `\~`, "~", `\{`, "{", `\}`, "}", `\#`, "#", `\:`, ":", `\n`, "\n", `\\`, `\`)
+
+func unescapeGIFT(text string) string {
+	return strings.TrimSpace(giftEscapes.Replace(text))
+}
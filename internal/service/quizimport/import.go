@@ -0,0 +1,161 @@
+package quizimport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"kahoot_bsu/internal/domain/models/question"
+	"kahoot_bsu/internal/service/markup"
+	"path/filepath"
+	"strings"
+)
+
+// Format of an imported file
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+	FormatGIFT Format = "gift"
+)
+
+// Limits of the imported questions
+const (
+	MinOptions = 2
+	// MaxOptions is the limit of Telegram polls used by group games
+	MaxOptions = 10
+
+	MinTimeLimit = 5
+	MaxTimeLimit = 600
+	MaxPoints    = 10000
+
+	DefaultTimeLimit = 30
+	DefaultPoints    = 100
+
+	maxTitle = 255
+)
+
+// ErrUnknownFormat is returned for the files of other formats
+var ErrUnknownFormat = errors.New("unknown quiz file format")
+
+// LineError is a problem with the question starting at the line,
+// the message is shown to the teacher as is
+type LineError struct {
+	Line    int
+	Message string
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Result is a parsed quiz, the questions with errors are left out of it
+type Result struct {
+	Title     string
+	Questions []question.Question
+	Errors    []LineError
+}
+
+// FormatOf picks the format by the extension of the file name,
+// .txt files are GIFT as Moodle exports them
+func FormatOf(filename string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".gift", ".txt":
+		return FormatGIFT, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Parse reads the quiz from the file. The title is taken from the file
+// when the format has one and from the file name otherwise.
+func Parse(format Format, filename string, r io.Reader) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quiz file: %w", err)
+	}
+
+	var result *Result
+	switch format {
+	case FormatCSV:
+		result, err = parseCSV(data)
+	case FormatJSON:
+		result, err = parseJSON(data)
+	case FormatGIFT:
+		result, err = parseGIFT(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Title = strings.TrimSpace(result.Title)
+	if result.Title == "" {
+		result.Title = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	if runes := []rune(result.Title); len(runes) > maxTitle {
+		result.Title = string(runes[:maxTitle])
+	}
+
+	return result, nil
+}
+
+// add validates the question and adds it to the result or its problems to the errors
+func (r *Result) add(line int, q question.Question) {
+	if q.TimeLimit == 0 {
+		q.TimeLimit = DefaultTimeLimit
+	}
+
+	var problems []string
+	if strings.TrimSpace(q.Text) == "" {
+		problems = append(problems, "пустой текст вопроса")
+	} else if text, err := markup.Sanitize(q.Text); err != nil {
+		problems = append(problems, fmt.Sprintf("текст вопроса: %v", err))
+	} else {
+		q.Text = text
+	}
+	if len(q.Options) < MinOptions || len(q.Options) > MaxOptions {
+		problems = append(problems, fmt.Sprintf("вариантов %d, нужно от %d до %d", len(q.Options), MinOptions, MaxOptions))
+	}
+
+	correct := 0
+	for i := range q.Options {
+		q.Options[i].Position = i
+		if strings.TrimSpace(q.Options[i].Text) == "" {
+			problems = append(problems, fmt.Sprintf("вариант %d пустой", i+1))
+		} else if text, err := markup.Sanitize(q.Options[i].Text); err != nil {
+			problems = append(problems, fmt.Sprintf("вариант %d: %v", i+1, err))
+		} else {
+			q.Options[i].Text = text
+		}
+		if q.Options[i].IsCorrect {
+			correct++
+		}
+	}
+	if correct != 1 {
+		problems = append(problems, fmt.Sprintf("правильных вариантов %d, нужен один", correct))
+	}
+
+	if q.TimeLimit < MinTimeLimit || q.TimeLimit > MaxTimeLimit {
+		problems = append(problems, fmt.Sprintf("время %d с, нужно от %d до %d", q.TimeLimit, MinTimeLimit, MaxTimeLimit))
+	}
+	if q.Points < 0 || q.Points > MaxPoints {
+		problems = append(problems, fmt.Sprintf("очков %d, нужно от 0 до %d", q.Points, MaxPoints))
+	}
+	if len(problems) == 0 {
+		if err := q.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		r.Errors = append(r.Errors, LineError{Line: line, Message: strings.Join(problems, "; ")})
+		return
+	}
+
+	r.Questions = append(r.Questions, q)
+}
@@ -0,0 +1,241 @@
+package quizimport
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// parsed is what a test expects of a question: its text, options and the correct one
+type parsed struct {
+	text    string
+	options []string
+	correct int
+}
+
+type parseTest struct {
+	name      string
+	input     string
+	want      []parsed
+	wantLines []int // lines of the errors
+}
+
+func runParseTests(t *testing.T, format Format, tests []parseTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(format, "quiz."+string(format), strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			var got []parsed
+			for _, q := range result.Questions {
+				p := parsed{text: q.Text, correct: -1}
+				for i, option := range q.Options {
+					p.options = append(p.options, option.Text)
+					if option.IsCorrect {
+						p.correct = i
+					}
+				}
+				got = append(got, p)
+			}
+			if !slices.EqualFunc(got, tt.want, func(a, b parsed) bool {
+				return a.text == b.text && a.correct == b.correct && slices.Equal(a.options, b.options)
+			}) {
+				t.Errorf("Parse() questions = %+v, want %+v", got, tt.want)
+			}
+
+			var lines []int
+			for _, e := range result.Errors {
+				lines = append(lines, e.Line)
+			}
+			if !slices.Equal(lines, tt.wantLines) {
+				t.Errorf("Parse() error lines = %v, want %v (errors: %v)", lines, tt.wantLines, result.Errors)
+			}
+		})
+	}
+}
+
+func TestParseGIFT(t *testing.T) {
+	runParseTests(t, FormatGIFT, []parseTest{
+		{
+			name:  "multiple choice",
+			input: "::Q1:: 2 + 2 = ? {\n=4\n~3\n~5\n}",
+			want:  []parsed{{text: "2 + 2 = ?", options: []string{"4", "3", "5"}, correct: 0}},
+		},
+		{
+			name:  "true false",
+			input: "The sky is green {F}",
+			want:  []parsed{{text: "The sky is green", options: []string{"Верно", "Неверно"}, correct: 1}},
+		},
+		{
+			name:  "comments, weights and feedback",
+			input: "// a comment\n$CATEGORY: math\n\nPick one {~%0%wrong#no ~%100%right#yes}",
+			want:  []parsed{{text: "Pick one", options: []string{"wrong", "right"}, correct: 1}},
+		},
+		{
+			name:  "escaped characters",
+			input: `Which is a brace \{ ? {=\{ ~\= ~\~}`,
+			want:  []parsed{{text: "Which is a brace { ?", options: []string{"{", "=", "~"}, correct: 0}},
+		},
+		{
+			name:  "escaped brace after the answers",
+			input: `Cats have {=four ~six} legs \}`,
+			want:  []parsed{{text: "Cats have _____ legs }", options: []string{"four", "six"}, correct: 0}},
+		},
+		{
+			name:  "BOM and CRLF",
+			input: "\uFEFFQ {=a ~b}\r\n\r\nR {=c ~d}",
+			want: []parsed{
+				{text: "Q", options: []string{"a", "b"}, correct: 0},
+				{text: "R", options: []string{"c", "d"}, correct: 0},
+			},
+		},
+		{
+			name:      "unsupported types",
+			input:     "Short answer {=cat}\n\nMatching {=a -> b =c -> d}\n\nNumeric {#4}",
+			wantLines: []int{1, 3, 5},
+		},
+		{
+			name:      "no answers",
+			input:     "Just text\n\nOK {=a ~b}",
+			want:      []parsed{{text: "OK", options: []string{"a", "b"}, correct: 0}},
+			wantLines: []int{1},
+		},
+		{
+			name:      "two correct answers",
+			input:     "Q {=a =b ~c}",
+			wantLines: []int{1},
+		},
+	})
+}
+
+func TestParseCSV(t *testing.T) {
+	runParseTests(t, FormatCSV, []parseTest{
+		{
+			name:  "header and defaults",
+			input: "question,time_limit,points,correct,option 1,option 2\n2 + 2,,,2,3,4\n",
+			want:  []parsed{{text: "2 + 2", options: []string{"3", "4"}, correct: 1}},
+		},
+		{
+			name:  "semicolons and quoted fields",
+			input: "\"Capital, of France\";20;100;1;Paris;Lyon;;\n",
+			want:  []parsed{{text: "Capital, of France", options: []string{"Paris", "Lyon"}, correct: 0}},
+		},
+		{
+			name:  "BOM before the header",
+			input: "\uFEFFquestion,time_limit,points,correct,a,b\nQ,30,100,1,x,y\n",
+			want:  []parsed{{text: "Q", options: []string{"x", "y"}, correct: 0}},
+		},
+		{
+			name:      "not numbers",
+			input:     "Q,soon,many,first,a,b\nR,30,100,1,a,b\n",
+			want:      []parsed{{text: "R", options: []string{"a", "b"}, correct: 0}},
+			wantLines: []int{1},
+		},
+		{
+			name:      "too few columns",
+			input:     "Q,30,100\n",
+			wantLines: []int{1},
+		},
+		{
+			name:      "correct option out of range",
+			input:     "Q,30,100,3,a,b\n",
+			wantLines: []int{1},
+		},
+		{
+			name:      "time limit out of range",
+			input:     "Q,1,100,1,a,b\n",
+			wantLines: []int{1},
+		},
+		{
+			name:      "broken quotes",
+			input:     "Q,30,100,1,a,b\nR,30,100,1,\"a,b\n",
+			want:      []parsed{{text: "Q", options: []string{"a", "b"}, correct: 0}},
+			wantLines: []int{2},
+		},
+		{
+			name:      "invalid markup",
+			input:     "**Q**,30,100,1,a,b\nR $x,30,100,1,a,b\nS,30,100,1,`a,b\n",
+			want:      []parsed{{text: "**Q**", options: []string{"a", "b"}, correct: 0}},
+			wantLines: []int{2, 3},
+		},
+	})
+}
+
+func TestParseJSON(t *testing.T) {
+	runParseTests(t, FormatJSON, []parseTest{
+		{
+			name: "questions",
+			input: `{
+  "title": "Math",
+  "questions": [
+    {"text": "2 + 2", "options": [{"text": "3"}, {"text": "4", "is_correct": true}]}
+  ]
+}`,
+			want: []parsed{{text: "2 + 2", options: []string{"3", "4"}, correct: 1}},
+		},
+		{
+			name: "unknown fields are skipped",
+			input: `{"id": "x", "tags": [1, 2], "questions": [
+  {"text": "Q", "extra": {}, "options": [{"text": "a", "is_correct": true}, {"text": "b"}]}
+]}`,
+			want: []parsed{{text: "Q", options: []string{"a", "b"}, correct: 0}},
+		},
+		{
+			name: "type error reports its question and continues",
+			input: `{"questions": [
+  {"text": "Q", "options": [{"text": "a", "is_correct": true}, {"text": "b"}]},
+  {"text": "R", "time_limit": "long", "options": []},
+  {"text": "S", "options": [{"text": "c", "is_correct": true}, {"text": "d"}]}
+]}`,
+			want: []parsed{
+				{text: "Q", options: []string{"a", "b"}, correct: 0},
+				{text: "S", options: []string{"c", "d"}, correct: 0},
+			},
+			wantLines: []int{3},
+		},
+		{
+			name: "invalid question",
+			input: `{"questions": [
+  {"text": "", "options": [{"text": "a"}]}
+]}`,
+			wantLines: []int{2},
+		},
+		{
+			name:      "syntax error",
+			input:     "{\n  \"questions\": [\n    {\"text\": }\n  ]\n}",
+			wantLines: []int{3},
+		},
+		{
+			name:      "no questions",
+			input:     `{"title": "Empty"}`,
+			wantLines: []int{1},
+		},
+		{
+			name:      "not an object",
+			input:     `[]`,
+			wantLines: []int{1},
+		},
+	})
+}
+
+func TestParseTitle(t *testing.T) {
+	result, err := Parse(FormatJSON, "ignored.json", strings.NewReader(`{"title": " Math ", "questions": []}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if result.Title != "Math" {
+		t.Errorf("Parse() title = %q, want %q", result.Title, "Math")
+	}
+
+	result, err = Parse(FormatGIFT, "/tmp/Biology 101.gift", strings.NewReader("Q {=a ~b}"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if result.Title != "Biology 101" {
+		t.Errorf("Parse() title = %q, want %q", result.Title, "Biology 101")
+	}
+}
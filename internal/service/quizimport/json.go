@@ -0,0 +1,132 @@
+package quizimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kahoot_bsu/internal/domain/models/question"
+)
+
+// jsonQuestion is the question of the web API, see quiz.Quiz
+type jsonQuestion struct {
+	Text       string              `json:"text"`
+	TimeLimit  int                 `json:"time_limit"`
+	Points     *int                `json:"points"`
+	PointsMode question.PointsMode `json:"points_mode"`
+	IsBonus    bool                `json:"is_bonus"`
+	Difficulty question.Difficulty `json:"difficulty"`
+	Options    []struct {
+		Text      string `json:"text"`
+		IsCorrect bool   `json:"is_correct"`
+	} `json:"options"`
+}
+
+// parseJSON reads a quiz in the format of the web API,
+// the questions are decoded one by one to report the line of each
+func parseJSON(data []byte) (*Result, error) {
+	result := &Result{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return jsonError(result, data, err)
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return jsonError(result, data, err)
+		}
+
+		switch token {
+		case "title":
+			if err := dec.Decode(&result.Title); err != nil {
+				return jsonError(result, data, err)
+			}
+
+		case "questions":
+			if err := expectDelim(dec, '['); err != nil {
+				return jsonError(result, data, err)
+			}
+			for dec.More() {
+				line := lineAt(data, dec.InputOffset())
+
+				var raw jsonQuestion
+				if err := dec.Decode(&raw); err != nil {
+					// The whole question is consumed on a type error, the next ones can be read
+					var typeErr *json.UnmarshalTypeError
+					if errors.As(err, &typeErr) {
+						result.Errors = append(result.Errors, LineError{Line: line, Message: err.Error()})
+						continue
+					}
+					return jsonError(result, data, err)
+				}
+
+				q := question.Question{
+					Text:       raw.Text,
+					TimeLimit:  raw.TimeLimit,
+					Points:     DefaultPoints,
+					PointsMode: raw.PointsMode,
+					IsBonus:    raw.IsBonus,
+					Difficulty: raw.Difficulty,
+				}
+				if raw.Points != nil {
+					q.Points = *raw.Points
+				}
+				for _, option := range raw.Options {
+					q.Options = append(q.Options, question.Option{Text: option.Text, IsCorrect: option.IsCorrect})
+				}
+
+				result.add(line, q)
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return jsonError(result, data, err)
+			}
+
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return jsonError(result, data, err)
+			}
+		}
+	}
+
+	if len(result.Questions) == 0 && len(result.Errors) == 0 {
+		result.Errors = append(result.Errors, LineError{Line: 1, Message: `в файле нет "questions"`})
+	}
+
+	return result, nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %q, found %v", delim, token)
+	}
+	return nil
+}
+
+// jsonError reports a broken file at the line of the error
+func jsonError(result *Result, data []byte, err error) (*Result, error) {
+	line := 1
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// The offset is past the character that broke the syntax
+		offset := min(max(syntaxErr.Offset-1, 0), int64(len(data)))
+		line = bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	result.Errors = append(result.Errors, LineError{Line: line, Message: err.Error()})
+	return result, nil
+}
+
+// lineAt returns the line of the first value at or after the offset
+func lineAt(data []byte, offset int64) int {
+	for offset < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n,"), data[offset]) >= 0 {
+		offset++
+	}
+	return bytes.Count(data[:min(offset, int64(len(data)))], []byte("\n")) + 1
+}
@@ -29,7 +29,7 @@ CREATE TABLE registation_fsm (
 
     wait_login BOOLEAN NOT NULL DEFAULT FALSE,
     wait_otp BOOLEAN NOT NULL DEFAULT FALSE,
-    is_registered BOOLEAN NOT NULL DEFAULT FALSE,
+    is_registered BOOLEAN NOT NULL DEFAULT FALSE
 );
 
 -- Create quizzes table
@@ -0,0 +1,6 @@
+ALTER TABLE participants
+    DROP COLUMN IF EXISTS seed;
+
+ALTER TABLE game_sessions
+    DROP COLUMN IF EXISTS shuffle_options,
+    DROP COLUMN IF EXISTS shuffle_questions;
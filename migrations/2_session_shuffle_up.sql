@@ -0,0 +1,10 @@
+-- Description:
+-- Per-participant shuffling of questions and options
+
+ALTER TABLE game_sessions
+    ADD COLUMN shuffle_questions BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN shuffle_options BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Seed for the participant's question and option order
+ALTER TABLE participants
+    ADD COLUMN seed BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_session_draws_question;
+DROP INDEX IF EXISTS idx_questions_pool;
+DROP INDEX IF EXISTS idx_question_pools_quiz;
+
+DROP TABLE IF EXISTS session_draws;
+
+ALTER TABLE game_sessions
+    DROP COLUMN IF EXISTS pool_draw;
+
+ALTER TABLE questions
+    DROP COLUMN IF EXISTS pool_id,
+    DROP COLUMN IF EXISTS difficulty;
+
+DROP TABLE IF EXISTS question_pools;
@@ -0,0 +1,31 @@
+-- Description:
+-- Question pools with random sampling per session or per participant
+
+CREATE TABLE question_pools (
+    id UUID PRIMARY KEY,
+    quiz_id UUID NOT NULL REFERENCES quizzes(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    draw_count INTEGER NOT NULL CHECK (draw_count > 0),
+    min_by_difficulty JSONB NOT NULL DEFAULT '{}' -- e.g. {"hard": 3}
+);
+
+ALTER TABLE questions
+    ADD COLUMN difficulty VARCHAR(10) NOT NULL DEFAULT 'medium',
+    ADD COLUMN pool_id UUID REFERENCES question_pools(id) ON DELETE SET NULL;
+
+-- 'session' draws one subset for everybody, 'participant' draws per participant
+ALTER TABLE game_sessions
+    ADD COLUMN pool_draw VARCHAR(12) NOT NULL DEFAULT 'session';
+
+-- Questions drawn for a session (participant_id IS NULL) or a participant
+CREATE TABLE session_draws (
+    session_id UUID NOT NULL REFERENCES game_sessions(id) ON DELETE CASCADE,
+    participant_id UUID REFERENCES participants(id) ON DELETE CASCADE,
+    question_id UUID NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL
+);
+
+CREATE INDEX idx_question_pools_quiz ON question_pools(quiz_id);
+CREATE INDEX idx_questions_pool ON questions(pool_id);
+CREATE UNIQUE INDEX idx_session_draws_question
+    ON session_draws(session_id, COALESCE(participant_id, '00000000-0000-0000-0000-000000000000'), question_id);
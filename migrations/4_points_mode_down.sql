@@ -0,0 +1,3 @@
+ALTER TABLE questions
+    DROP COLUMN IF EXISTS is_bonus,
+    DROP COLUMN IF EXISTS points_mode;
@@ -0,0 +1,6 @@
+-- Description:
+-- Per-question score multipliers and bonus questions
+
+ALTER TABLE questions
+    ADD COLUMN points_mode VARCHAR(10) NOT NULL DEFAULT 'standard', -- standard, double, none
+    ADD COLUMN is_bonus BOOLEAN NOT NULL DEFAULT FALSE;
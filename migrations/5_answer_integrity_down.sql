@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_participant_flags_session;
+
+DROP TABLE IF EXISTS participant_flags;
+
+ALTER TABLE game_sessions
+    DROP COLUMN IF EXISTS question_opened_at;
+
+ALTER TABLE answers
+    DROP CONSTRAINT IF EXISTS uq_answers_participant_question;
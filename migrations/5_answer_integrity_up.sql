@@ -0,0 +1,23 @@
+-- Description:
+-- Answer submission integrity and anti-cheat flags
+
+-- One answer per participant per question
+ALTER TABLE answers
+    ADD CONSTRAINT uq_answers_participant_question UNIQUE (participant_id, question_id);
+
+-- When the current question was opened, answers are accepted until its time limit
+ALTER TABLE game_sessions
+    ADD COLUMN question_opened_at TIMESTAMPTZ;
+
+-- Suspicious answering patterns shown in the post-game report
+CREATE TABLE participant_flags (
+    id UUID PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES game_sessions(id) ON DELETE CASCADE,
+    participant_id UUID NOT NULL REFERENCES participants(id) ON DELETE CASCADE,
+    question_id UUID REFERENCES questions(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL, -- fast_answer, timing_cluster
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_participant_flags_session ON participant_flags(session_id);
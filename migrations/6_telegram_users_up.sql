@@ -0,0 +1,5 @@
+-- Description:
+-- Link users to their Telegram accounts for Mini App authentication
+
+ALTER TABLE users
+    ADD COLUMN telegram_id BIGINT UNIQUE;
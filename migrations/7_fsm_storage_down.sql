@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS idx_verification_codes_telegram;
+
+DELETE FROM verification_codes WHERE user_id IS NULL;
+
+ALTER TABLE verification_codes
+    DROP COLUMN IF EXISTS telegram_id,
+    ALTER COLUMN user_id SET NOT NULL;
+
+DROP TABLE IF EXISTS registation_fsm;
+
+CREATE TABLE registation_fsm (
+    id UUID PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    wait_login BOOLEAN NOT NULL DEFAULT FALSE,
+    wait_otp BOOLEAN NOT NULL DEFAULT FALSE,
+    is_registered BOOLEAN NOT NULL DEFAULT FALSE
+);
@@ -0,0 +1,20 @@
+-- Description:
+-- Keep the FSM state of Telegram conversations in registation_fsm and
+-- let verification codes be issued before the user is registered
+
+DROP TABLE registation_fsm;
+
+CREATE TABLE registation_fsm (
+    chat_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL, -- Telegram user ID
+    state VARCHAR(64) NOT NULL DEFAULT '',
+    data JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chat_id, user_id)
+);
+
+ALTER TABLE verification_codes
+    ALTER COLUMN user_id DROP NOT NULL,
+    ADD COLUMN telegram_id BIGINT;
+
+CREATE INDEX idx_verification_codes_telegram ON verification_codes(telegram_id);
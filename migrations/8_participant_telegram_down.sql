@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_participants_session_telegram;
+
+ALTER TABLE participants
+    DROP COLUMN IF EXISTS telegram_id;
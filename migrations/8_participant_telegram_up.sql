@@ -0,0 +1,10 @@
+-- Description:
+-- Remember the Telegram user of a participant, so joining a session
+-- again in the bot returns the same participant
+
+ALTER TABLE participants
+    ADD COLUMN telegram_id BIGINT;
+
+CREATE UNIQUE INDEX idx_participants_session_telegram
+    ON participants(session_id, telegram_id)
+    WHERE telegram_id IS NOT NULL;
@@ -0,0 +1,42 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// Medium error correction survives a projector glare or a bad camera angle
+const recoveryLevel = qrcode.Medium
+
+// PNG encodes content as a QR code image of size×size pixels
+func PNG(content string, size int) ([]byte, error) {
+	return qrcode.Encode(content, recoveryLevel, size)
+}
+
+// SVG encodes content as a QR code drawing of one unit per module,
+// it scales to any size without blurring
+func SVG(content string) ([]byte, error) {
+	code, err := qrcode.New(content, recoveryLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	// The bitmap includes the quiet zone around the code
+	bitmap := code.Bitmap()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, len(bitmap), len(bitmap))
+	b.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket: it allows bursts of up to burst events
+// and refills at rate events per second
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow reports whether an event may happen now and spends a token if so
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// Keyed keeps a Limiter per key, e.g. per connection.
+// Limiters idle for longer than ttl are dropped.
+type Keyed struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    int
+	ttl      time.Duration
+	limiters map[string]*keyedLimiter
+}
+
+type keyedLimiter struct {
+	*Limiter
+	lastSeen time.Time
+}
+
+func NewKeyed(rate float64, burst int, ttl time.Duration) *Keyed {
+	return &Keyed{
+		rate:     rate,
+		burst:    burst,
+		ttl:      ttl,
+		limiters: make(map[string]*keyedLimiter),
+	}
+}
+
+// Allow reports whether an event for key may happen now
+func (k *Keyed) Allow(key string) bool {
+	k.mu.Lock()
+
+	now := time.Now()
+	limiter, ok := k.limiters[key]
+	if !ok {
+		k.cleanup(now)
+		limiter = &keyedLimiter{Limiter: New(k.rate, k.burst)}
+		k.limiters[key] = limiter
+	}
+	limiter.lastSeen = now
+
+	k.mu.Unlock()
+
+	return limiter.Allow()
+}
+
+// cleanup drops idle limiters, must be called with k.mu held
+func (k *Keyed) cleanup(now time.Time) {
+	for key, limiter := range k.limiters {
+		if now.Sub(limiter.lastSeen) > k.ttl {
+			delete(k.limiters, key)
+		}
+	}
+}
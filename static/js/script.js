@@ -10,6 +10,11 @@ document.addEventListener('DOMContentLoaded', function() {
     // API Base URL
     const API_BASE_URL = '/api';
 
+    // Init data signed by Telegram, authenticates the user to the API
+    const telegramInitData = window.Telegram && window.Telegram.WebApp
+        ? window.Telegram.WebApp.initData
+        : '';
+
     // Elements
     const tabButtons = document.querySelectorAll('.tab-btn');
     const tabPanes = document.querySelectorAll('.tab-pane');
@@ -61,6 +66,14 @@ document.addEventListener('DOMContentLoaded', function() {
     // Initialize app
     loadQuizzes();
 
+    // fetch with the Telegram authentication header
+    function apiFetch(url, options = {}) {
+        const headers = Object.assign({}, options.headers, {
+            'Authorization': `tma ${telegramInitData}`
+        });
+        return fetch(url, Object.assign({}, options, { headers }));
+    }
+
     // Switch tab function
     function switchTab(tabId) {
         tabButtons.forEach(btn => {
@@ -81,7 +94,7 @@ document.addEventListener('DOMContentLoaded', function() {
     // Load quizzes from API
     async function loadQuizzes() {
         try {
-            const response = await fetch(`${API_BASE_URL}/quizzes`);
+            const response = await apiFetch(`${API_BASE_URL}/quizzes`);
             if (!response.ok) throw new Error('Failed to fetch quizzes');
             
             const quizzes = await response.json();
@@ -143,7 +156,7 @@ document.addEventListener('DOMContentLoaded', function() {
         }
 
         try {
-            const response = await fetch(`${API_BASE_URL}/quizzes`, {
+            const response = await apiFetch(`${API_BASE_URL}/quizzes`, {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json'
@@ -165,7 +178,7 @@ document.addEventListener('DOMContentLoaded', function() {
     // Load quiz detail
     async function loadQuizDetail(quizId) {
         try {
-            const response = await fetch(`${API_BASE_URL}/quizzes/${quizId}`);
+            const response = await apiFetch(`${API_BASE_URL}/quizzes/${quizId}`);
             if (!response.ok) throw new Error('Failed to fetch quiz details');
             
             const quiz = await response.json();
@@ -193,7 +206,7 @@ document.addEventListener('DOMContentLoaded', function() {
         questionsList.innerHTML = '<div class="loading-spinner"></div>';
         
         try {
-            const response = await fetch(`${API_BASE_URL}/quizzes/${quizId}/questions`);
+            const response = await apiFetch(`${API_BASE_URL}/quizzes/${quizId}/questions`);
             if (!response.ok) throw new Error('Failed to fetch questions');
             
             const questions = await response.json();
@@ -234,7 +247,7 @@ document.addEventListener('DOMContentLoaded', function() {
                     const isCorrect = option.is_correct ? 'correct' : '';
                     optionsHTML += `
                         <div class="option-card ${isCorrect}">
-                            ${option.text}
+                            ${option.text_html || option.text}
                             ${option.is_correct ? '<span class="badge">✓ Correct</span>' : ''}
                         </div>
                     `;
@@ -245,10 +258,11 @@ document.addEventListener('DOMContentLoaded', function() {
             card.innerHTML = `
                 <div class="question-header">
                     <div class="question-content">
-                        <h3>Q${index + 1}: ${question.text}</h3>
+                        <h3>Q${index + 1}: ${question.text_html || question.text}</h3>
                         <div class="question-meta">
                             <span><i class="fas fa-clock"></i> ${question.time_limit}s</span>
-                            <span><i class="fas fa-star"></i> ${question.points} points</span>
+                            <span><i class="fas fa-star"></i> ${formatPoints(question)}</span>
+                            ${question.is_bonus ? '<span><i class="fas fa-gift"></i> Bonus</span>' : ''}
                         </div>
                     </div>
                     <div class="question-actions">
@@ -264,6 +278,13 @@ document.addEventListener('DOMContentLoaded', function() {
             `;
             
             questionsList.appendChild(card);
+
+            // Typeset formulas rendered by the server as \( ... \)
+            if (window.renderMathInElement) {
+                renderMathInElement(card, {
+                    delimiters: [{ left: '\\(', right: '\\)', display: false }],
+                });
+            }
             
             // Add event listeners
             card.querySelector('.edit-question').addEventListener('click', () => {
@@ -276,6 +297,18 @@ document.addEventListener('DOMContentLoaded', function() {
         });
     }
 
+    // Format question points according to its points mode
+    function formatPoints(question) {
+        switch (question.points_mode) {
+            case 'double':
+                return `${question.points * 2} points (double)`;
+            case 'none':
+                return 'No points';
+            default:
+                return `${question.points} points`;
+        }
+    }
+
     // Show edit quiz form
     function showEditQuizForm() {
         document.getElementById('edit-quiz-title').value = state.currentQuiz.title;
@@ -293,7 +326,7 @@ document.addEventListener('DOMContentLoaded', function() {
         }
 
         try {
-            const response = await fetch(`${API_BASE_URL}/quizzes/${state.currentQuiz.uuid}`, {
+            const response = await apiFetch(`${API_BASE_URL}/quizzes/${state.currentQuiz.uuid}`, {
                 method: 'PUT',
                 headers: {
                     'Content-Type': 'application/json'
@@ -363,6 +396,8 @@ document.addEventListener('DOMContentLoaded', function() {
         document.getElementById('question-text').value = question.text;
         document.getElementById('question-time-limit').value = question.time_limit;
         document.getElementById('question-points').value = question.points;
+        document.getElementById('question-points-mode').value = question.points_mode || 'standard';
+        document.getElementById('question-is-bonus').checked = !!question.is_bonus;
         
         // Create options
         optionsList.innerHTML = '';
@@ -391,6 +426,8 @@ document.addEventListener('DOMContentLoaded', function() {
         const questionText = document.getElementById('question-text').value.trim();
         const timeLimit = parseInt(document.getElementById('question-time-limit').value);
         const points = parseInt(document.getElementById('question-points').value);
+        const pointsMode = document.getElementById('question-points-mode').value;
+        const isBonus = document.getElementById('question-is-bonus').checked;
         
         if (!questionText) {
             alert('Please enter question text');
@@ -421,6 +458,8 @@ document.addEventListener('DOMContentLoaded', function() {
             text: questionText,
             time_limit: timeLimit,
             points: points,
+            points_mode: pointsMode,
+            is_bonus: isBonus,
             options: options
         };
         
@@ -429,7 +468,7 @@ document.addEventListener('DOMContentLoaded', function() {
             
             if (state.isEditingQuestion) {
                 // Update existing question
-                response = await fetch(`${API_BASE_URL}/questions/${state.currentQuestion.uuid}`, {
+                response = await apiFetch(`${API_BASE_URL}/questions/${state.currentQuestion.uuid}`, {
                     method: 'PUT',
                     headers: {
                         'Content-Type': 'application/json'
@@ -438,7 +477,7 @@ document.addEventListener('DOMContentLoaded', function() {
                 });
             } else {
                 // Add new question
-                response = await fetch(`${API_BASE_URL}/quizzes/${state.currentQuiz.uuid}/questions`, {
+                response = await apiFetch(`${API_BASE_URL}/quizzes/${state.currentQuiz.uuid}/questions`, {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json'
@@ -525,7 +564,7 @@ document.addEventListener('DOMContentLoaded', function() {
         hideModal();
         
         try {
-            const response = await fetch(`${API_BASE_URL}/quizzes/${state.currentQuiz.uuid}`, {
+            const response = await apiFetch(`${API_BASE_URL}/quizzes/${state.currentQuiz.uuid}`, {
                 method: 'DELETE'
             });
 
@@ -555,7 +594,7 @@ document.addEventListener('DOMContentLoaded', function() {
         hideModal();
         
         try {
-            const response = await fetch(`${API_BASE_URL}/questions/${questionId}`, {
+            const response = await apiFetch(`${API_BASE_URL}/questions/${questionId}`, {
                 method: 'DELETE'
             });
 